@@ -201,8 +201,8 @@ function LaunchInstance() {
               className="form-input"
             >
               {securityGroups.map((group) => (
-                <option key={group.GroupId} value={group.GroupId}>
-                  {group.GroupName}
+                <option key={group.groupId} value={group.groupId}>
+                  {group.groupName}
                 </option>
               ))}
             </select>
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 
 	"github.com/turaneminli/go_backend_aws/internal/handlers"
 	"github.com/turaneminli/go_backend_aws/internal/router"
@@ -16,8 +19,30 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to create EC2 client: %v", err)
 	}
-	ec2Service := &services.EC2Service{Client: ec2Client}
-	ec2Handler := &handlers.EC2Handler{Service: ec2Service}
+	ec2Service := services.NewEC2Service(ec2Client)
+
+	ssmClient, err := utils.NewSSMClient()
+	if err != nil {
+		log.Fatalf("failed to create SSM client: %v", err)
+	}
+	ec2Service.SSM = ssmClient
+
+	// Local state such as schedules and bucket snapshots lives under DATA_DIR
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = "data"
+	}
+
+	scheduler, err := services.NewScheduler(ec2Service, filepath.Join(dataDir, "schedules.json"))
+	if err != nil {
+		log.Fatalf("failed to initialize scheduler: %v", err)
+	}
+	go scheduler.Run(context.Background())
+	ec2Handler := &handlers.EC2Handler{
+		Service:   ec2Service,
+		Scheduler: scheduler,
+		Network:   &services.NetworkService{EC2: ec2Service},
+	}
 
 	// Initialize CloudWatch client and service
 	cloudWatchClient, err := utils.CreateCloudWatchClient()
@@ -27,13 +52,28 @@ func main() {
 	cloudWatchService := &services.CloudWatchService{Client: cloudWatchClient}
 	cloudWatchHandler := &handlers.CloudWatchHandler{Service: cloudWatchService}
 
+	// Initialize the pricing client used for cost estimates
+	pricingClient, err := utils.NewPricingClient()
+	if err != nil {
+		log.Fatalf("failed to create pricing client: %v", err)
+	}
+	ec2Handler.Recommendations = &services.RecommendationService{
+		EC2:        ec2Service,
+		CloudWatch: cloudWatchService,
+		Pricing:    services.NewPricingService(pricingClient),
+	}
+
 	// Initialize S3 client and service
 	s3Client, err := utils.NewS3Client()
 	if err != nil {
 		log.Fatalf("failed to create S3 client: %v", err)
 	}
-	s3Service := &services.S3Service{Client: s3Client}
-	s3Handler := &handlers.S3Handler{Service: s3Service}
+	s3Service := services.NewS3Service(s3Client)
+	s3Service.Snapshots, err = services.NewSnapshotStore(filepath.Join(dataDir, "snapshots"))
+	if err != nil {
+		log.Fatalf("failed to initialize snapshot storage: %v", err)
+	}
+	s3Handler := &handlers.S3Handler{Service: s3Service, CloudWatch: cloudWatchService}
 
 	// Initialize the router
 	r := router.NewRouter(ec2Handler, cloudWatchHandler, s3Handler)
@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/turaneminli/go_backend_aws/internal/services"
 )
 
 type EC2Handler struct {
-	Service *services.EC2Service
+	Service         *services.EC2Service
+	Scheduler       *services.Scheduler
+	Recommendations *services.RecommendationService
+	Network         *services.NetworkService
 }
 
 type Response struct {
@@ -17,6 +21,13 @@ type Response struct {
 	InstanceID string `json:"instance_id"`
 }
 
+// LaunchResponse reports every instance started by a launch request
+type LaunchResponse struct {
+	Message     string   `json:"message"`
+	InstanceID  string   `json:"instance_id"`
+	InstanceIDs []string `json:"instance_ids"`
+}
+
 func (h *EC2Handler) ListRegionsHandler(w http.ResponseWriter, r *http.Request) {
 	regions, err := h.Service.ListRegions()
 	if err != nil {
@@ -38,16 +49,21 @@ func (h *EC2Handler) LaunchInstanceHandler(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
 
-	instanceID, err := h.Service.LaunchInstance(input)
+	instanceIDs, err := h.Service.LaunchInstance(input)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	response := Response{
-		Message:    "Instance launched successfully",
-		InstanceID: instanceID,
+	response := LaunchResponse{
+		Message:     fmt.Sprintf("%d instance(s) launched successfully", len(instanceIDs)),
+		InstanceID:  instanceIDs[0],
+		InstanceIDs: instanceIDs,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -56,6 +72,26 @@ func (h *EC2Handler) LaunchInstanceHandler(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
+// PreflightLaunchHandler checks a launch request against the target region without launching anything
+func (h *EC2Handler) PreflightLaunchHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.LaunchInstanceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Service.PreflightLaunch(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "Failed to encode preflight result to JSON", http.StatusInternalServerError)
+	}
+}
+
 func (h *EC2Handler) StopInstanceByIdHandler(w http.ResponseWriter, r *http.Request) {
 	instanceID := r.URL.Query().Get("instanceId")
 
@@ -121,7 +157,7 @@ func (h *EC2Handler) ListRunningInstancesStatusHandler(w http.ResponseWriter, r
 }
 
 func (h *EC2Handler) ListSecurityGroupsHandler(w http.ResponseWriter, r *http.Request) {
-	securityGroups, err := h.Service.ListSecurityGroups()
+	securityGroups, err := h.Service.ListSecurityGroups(r.URL.Query().Get("region"), r.URL.Query().Get("vpcId"))
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to retrieve security groups: %v", err), http.StatusInternalServerError)
 		return
@@ -208,3 +244,1039 @@ func (h *EC2Handler) RebootInstanceByIdHandler(w http.ResponseWriter, r *http.Re
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// ListLaunchTemplatesHandler lists the launch templates in a region
+func (h *EC2Handler) ListLaunchTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.Service.ListLaunchTemplates(r.URL.Query().Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(templates); err != nil {
+		http.Error(w, "Failed to encode launch templates to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ListLaunchTemplateVersionsHandler lists the versions of a launch template
+func (h *EC2Handler) ListLaunchTemplateVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	templateID := r.URL.Query().Get("templateId")
+	if templateID == "" {
+		http.Error(w, "Template ID is required", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := h.Service.ListLaunchTemplateVersions(r.URL.Query().Get("region"), templateID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(versions); err != nil {
+		http.Error(w, "Failed to encode launch template versions to JSON", http.StatusInternalServerError)
+	}
+}
+
+// LaunchFromTemplateHandler launches a batch of instances from a launch template
+func (h *EC2Handler) LaunchFromTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.LaunchFromTemplateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	instanceIDs, err := h.Service.LaunchFromTemplate(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := LaunchResponse{
+		Message:     fmt.Sprintf("%d instance(s) launched successfully", len(instanceIDs)),
+		InstanceID:  instanceIDs[0],
+		InstanceIDs: instanceIDs,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateTemplateFromInstanceHandler creates a launch template from an existing instance
+func (h *EC2Handler) CreateTemplateFromInstanceHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CreateTemplateFromInstanceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	template, err := h.Service.CreateTemplateFromInstance(input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// SpotPriceHistoryHandler returns current and recent spot prices per instance type and availability zone
+func (h *EC2Handler) SpotPriceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	days := 1
+	if value := query.Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "days must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	history, err := h.Service.GetSpotPriceHistory(query.Get("region"), query.Get("instanceType"), query.Get("availabilityZone"), query.Get("product"), days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		http.Error(w, "Failed to encode spot prices to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ListSchedulesHandler lists instance schedules with their next start and stop times
+func (h *EC2Handler) ListSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.Scheduler.List()); err != nil {
+		http.Error(w, "Failed to encode schedules to JSON", http.StatusInternalServerError)
+	}
+}
+
+// CreateScheduleHandler creates an instance schedule
+func (h *EC2Handler) CreateScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.ScheduleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.Scheduler.Create(input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// UpdateScheduleHandler replaces an instance schedule
+func (h *EC2Handler) UpdateScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	scheduleID := r.URL.Query().Get("scheduleId")
+	if scheduleID == "" {
+		http.Error(w, "Schedule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var input services.ScheduleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.Scheduler.Update(scheduleID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// DeleteScheduleHandler removes an instance schedule
+func (h *EC2Handler) DeleteScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	scheduleID := r.URL.Query().Get("scheduleId")
+	if scheduleID == "" {
+		http.Error(w, "Schedule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Scheduler.Delete(scheduleID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":     "Schedule deleted successfully",
+		"schedule_id": scheduleID,
+	})
+}
+
+// ScheduleHistoryHandler returns recent schedule runs, optionally for one schedule
+func (h *EC2Handler) ScheduleHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.Scheduler.History(r.URL.Query().Get("scheduleId"), limit)); err != nil {
+		http.Error(w, "Failed to encode schedule history to JSON", http.StatusInternalServerError)
+	}
+}
+
+// IdleInstancesHandler reports running instances that stayed below CPU and network thresholds
+func (h *EC2Handler) IdleInstancesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var criteria services.IdleCriteria
+	var err error
+	if value := query.Get("days"); value != "" {
+		if criteria.Days, err = strconv.Atoi(value); err != nil || criteria.Days < 1 {
+			http.Error(w, "days must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("cpuThreshold"); value != "" {
+		if criteria.CPUThresholdPercent, err = strconv.ParseFloat(value, 64); err != nil || criteria.CPUThresholdPercent <= 0 {
+			http.Error(w, "cpuThreshold must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("networkThresholdMB"); value != "" {
+		if criteria.NetworkThresholdMB, err = strconv.ParseFloat(value, 64); err != nil || criteria.NetworkThresholdMB <= 0 {
+			http.Error(w, "networkThresholdMB must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.Recommendations.FindIdleInstances(criteria)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode idle instance report to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ListInstanceTypesHandler lists the instance types of a region, filtered by query parameters
+func (h *EC2Handler) ListInstanceTypesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := services.InstanceTypeFilter{
+		Search:             query.Get("search"),
+		Family:             query.Get("family"),
+		Architecture:       query.Get("architecture"),
+		AvailabilityZone:   query.Get("availabilityZone"),
+		NetworkPerformance: query.Get("networkPerformance"),
+		CurrentGeneration:  query.Get("currentGeneration") == "true",
+	}
+
+	for name, target := range map[string]*int32{"minVcpus": &filter.MinVCPUs, "maxVcpus": &filter.MaxVCPUs} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || parsed < 0 {
+				http.Error(w, name+" must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			*target = int32(parsed)
+		}
+	}
+	for name, target := range map[string]*float64{"minMemoryGiB": &filter.MinMemoryGiB, "maxMemoryGiB": &filter.MaxMemoryGiB} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < 0 {
+				http.Error(w, name+" must be a non-negative number", http.StatusBadRequest)
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	instanceTypes, err := h.Service.ListInstanceTypes(query.Get("region"), filter, query.Get("refresh") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(instanceTypes); err != nil {
+		http.Error(w, "Failed to encode instance types to JSON", http.StatusInternalServerError)
+	}
+}
+
+// SearchImagesHandler searches AMIs by owner, name pattern and architecture
+func (h *EC2Handler) SearchImagesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	images, err := h.Service.SearchImages(services.ImageSearch{
+		Region:       query.Get("region"),
+		Owner:        query.Get("owner"),
+		Name:         query.Get("name"),
+		Architecture: query.Get("architecture"),
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(images); err != nil {
+		http.Error(w, "Failed to encode images to JSON", http.StatusInternalServerError)
+	}
+}
+
+// LatestPublicImagesHandler returns the latest Amazon-published AMIs by OS
+func (h *EC2Handler) LatestPublicImagesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	images, err := h.Service.LatestPublicImages(query.Get("region"), query.Get("os"), query.Get("architecture"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(images); err != nil {
+		http.Error(w, "Failed to encode images to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ImageDetailHandler returns the details of an AMI
+func (h *EC2Handler) ImageDetailHandler(w http.ResponseWriter, r *http.Request) {
+	imageID := r.URL.Query().Get("imageId")
+	if imageID == "" {
+		http.Error(w, "Image ID is required", http.StatusBadRequest)
+		return
+	}
+
+	image, err := h.Service.GetImage(r.URL.Query().Get("region"), imageID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(image); err != nil {
+		http.Error(w, "Failed to encode image to JSON", http.StatusInternalServerError)
+	}
+}
+
+// CreateImageHandler creates an AMI from an instance
+func (h *EC2Handler) CreateImageHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CreateImageInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	imageID, err := h.Service.CreateImage(input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":  "Image creation started",
+		"image_id": imageID,
+	})
+}
+
+// DeregisterImageHandler deregisters an AMI and deletes its snapshots unless deleteSnapshots=false
+func (h *EC2Handler) DeregisterImageHandler(w http.ResponseWriter, r *http.Request) {
+	imageID := r.URL.Query().Get("imageId")
+	if imageID == "" {
+		http.Error(w, "Image ID is required", http.StatusBadRequest)
+		return
+	}
+	deleteSnapshots := r.URL.Query().Get("deleteSnapshots") != "false"
+
+	result, err := h.Service.DeregisterImage(r.URL.Query().Get("region"), imageID, deleteSnapshots)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// CopyImageHandler copies an AMI into another region
+func (h *EC2Handler) CopyImageHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CopyImageInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	imageID, err := h.Service.CopyImage(input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":  "Image copy started",
+		"image_id": imageID,
+	})
+}
+
+// ListVolumesHandler lists EBS volumes, optionally filtered by instance, state and type
+func (h *EC2Handler) ListVolumesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	volumes, err := h.Service.ListVolumes(services.VolumeFilter{
+		Region:     query.Get("region"),
+		InstanceID: query.Get("instanceId"),
+		State:      query.Get("state"),
+		VolumeType: query.Get("volumeType"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(volumes); err != nil {
+		http.Error(w, "Failed to encode volumes to JSON", http.StatusInternalServerError)
+	}
+}
+
+// VolumeDetailHandler returns the details of an EBS volume
+func (h *EC2Handler) VolumeDetailHandler(w http.ResponseWriter, r *http.Request) {
+	volumeID := r.URL.Query().Get("volumeId")
+	if volumeID == "" {
+		http.Error(w, "Volume ID is required", http.StatusBadRequest)
+		return
+	}
+
+	volume, err := h.Service.GetVolume(r.URL.Query().Get("region"), volumeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(volume); err != nil {
+		http.Error(w, "Failed to encode volume to JSON", http.StatusInternalServerError)
+	}
+}
+
+// CreateVolumeHandler creates an EBS volume
+func (h *EC2Handler) CreateVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CreateVolumeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	volumeID, err := h.Service.CreateVolume(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":   "Volume created successfully",
+		"volume_id": volumeID,
+	})
+}
+
+// AttachVolumeHandler attaches an EBS volume to an instance
+func (h *EC2Handler) AttachVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.AttachVolumeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	state, err := h.Service.AttachVolume(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":     "Volume attachment started",
+		"volume_id":   input.VolumeID,
+		"instance_id": input.InstanceID,
+		"state":       state,
+	})
+}
+
+// DetachVolumeHandler detaches an EBS volume; force=true skips a clean unmount
+func (h *EC2Handler) DetachVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	volumeID := r.URL.Query().Get("volumeId")
+	if volumeID == "" {
+		http.Error(w, "Volume ID is required", http.StatusBadRequest)
+		return
+	}
+	force := r.URL.Query().Get("force") == "true"
+
+	state, err := h.Service.DetachVolume(r.URL.Query().Get("region"), volumeID, force)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":   "Volume detachment started",
+		"volume_id": volumeID,
+		"state":     state,
+	})
+}
+
+// ModifyVolumeHandler resizes an EBS volume or changes its type and performance
+func (h *EC2Handler) ModifyVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.ModifyVolumeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	modification, err := h.Service.ModifyVolume(input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(modification)
+}
+
+// DeleteVolumeHandler deletes a detached EBS volume
+func (h *EC2Handler) DeleteVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	volumeID := r.URL.Query().Get("volumeId")
+	if volumeID == "" {
+		http.Error(w, "Volume ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteVolume(r.URL.Query().Get("region"), volumeID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":   "Volume deleted successfully",
+		"volume_id": volumeID,
+	})
+}
+
+// ListSnapshotsHandler lists EBS snapshots owned by this account, optionally of one volume
+func (h *EC2Handler) ListSnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	snapshots, err := h.Service.ListSnapshots(r.URL.Query().Get("region"), r.URL.Query().Get("volumeId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshots); err != nil {
+		http.Error(w, "Failed to encode snapshots to JSON", http.StatusInternalServerError)
+	}
+}
+
+// CreateSnapshotHandler starts a snapshot of an EBS volume
+func (h *EC2Handler) CreateSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CreateSnapshotInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	snapshotID, err := h.Service.CreateSnapshot(input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":     "Snapshot creation started",
+		"snapshot_id": snapshotID,
+	})
+}
+
+// DeleteSnapshotHandler deletes an EBS snapshot
+func (h *EC2Handler) DeleteSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	snapshotID := r.URL.Query().Get("snapshotId")
+	if snapshotID == "" {
+		http.Error(w, "Snapshot ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteSnapshot(r.URL.Query().Get("region"), snapshotID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":     "Snapshot deleted successfully",
+		"snapshot_id": snapshotID,
+	})
+}
+
+// UnusedStorageHandler reports unattached volumes and orphaned snapshots
+func (h *EC2Handler) UnusedStorageHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := h.Service.FindUnusedStorage(r.URL.Query().Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode report to JSON", http.StatusInternalServerError)
+	}
+}
+
+// SecurityGroupDetailHandler returns a security group with its rules
+func (h *EC2Handler) SecurityGroupDetailHandler(w http.ResponseWriter, r *http.Request) {
+	groupID := r.URL.Query().Get("groupId")
+	if groupID == "" {
+		http.Error(w, "Group ID is required", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.Service.GetSecurityGroup(r.URL.Query().Get("region"), groupID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(group); err != nil {
+		http.Error(w, "Failed to encode security group to JSON", http.StatusInternalServerError)
+	}
+}
+
+// CreateSecurityGroupHandler creates a security group
+func (h *EC2Handler) CreateSecurityGroupHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CreateSecurityGroupInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	groupID, err := h.Service.CreateSecurityGroup(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":  "Security group created successfully",
+		"group_id": groupID,
+	})
+}
+
+// DeleteSecurityGroupHandler deletes a security group
+func (h *EC2Handler) DeleteSecurityGroupHandler(w http.ResponseWriter, r *http.Request) {
+	groupID := r.URL.Query().Get("groupId")
+	if groupID == "" {
+		http.Error(w, "Group ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteSecurityGroup(r.URL.Query().Get("region"), groupID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":  "Security group deleted successfully",
+		"group_id": groupID,
+	})
+}
+
+// AuthorizeRulesHandler adds inbound or outbound rules to a security group
+func (h *EC2Handler) AuthorizeRulesHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.AuthorizeRulesInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	rules, err := h.Service.AuthorizeRules(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rules)
+}
+
+// RevokeRulesHandler removes rules from a security group by rule ID
+func (h *EC2Handler) RevokeRulesHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.RevokeRulesInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.Service.RevokeRules(input); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":  fmt.Sprintf("%d rule(s) revoked successfully", len(input.RuleIDs)),
+		"group_id": input.GroupID,
+	})
+}
+
+// SecurityGroupFindingsHandler lists inbound rules that expose sensitive ports to the internet
+func (h *EC2Handler) SecurityGroupFindingsHandler(w http.ResponseWriter, r *http.Request) {
+	findings, err := h.Service.FindOpenSecurityGroupRules(r.URL.Query().Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(findings); err != nil {
+		http.Error(w, "Failed to encode findings to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ListKeyPairsHandler lists the key pairs of a region
+func (h *EC2Handler) ListKeyPairsHandler(w http.ResponseWriter, r *http.Request) {
+	keyPairs, err := h.Service.ListKeyPairs(r.URL.Query().Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(keyPairs); err != nil {
+		http.Error(w, "Failed to encode key pairs to JSON", http.StatusInternalServerError)
+	}
+}
+
+// CreateKeyPairHandler creates a key pair and returns its private key, which cannot be retrieved again
+func (h *EC2Handler) CreateKeyPairHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CreateKeyPairInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	keyPair, err := h.Service.CreateKeyPair(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The private key must not be kept by browsers or proxies
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(keyPair)
+}
+
+// ImportKeyPairHandler registers an existing public key as a key pair
+func (h *EC2Handler) ImportKeyPairHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.ImportKeyPairInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	keyPair, err := h.Service.ImportKeyPair(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(keyPair)
+}
+
+// DeleteKeyPairHandler deletes a key pair by keyName or keyPairId
+func (h *EC2Handler) DeleteKeyPairHandler(w http.ResponseWriter, r *http.Request) {
+	keyName := r.URL.Query().Get("keyName")
+	keyPairID := r.URL.Query().Get("keyPairId")
+	if keyName == "" && keyPairID == "" {
+		http.Error(w, "Key name or key pair ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteKeyPair(r.URL.Query().Get("region"), keyName, keyPairID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Key pair deleted successfully",
+	})
+}
+
+// ListVpcsHandler lists the VPCs of a region
+func (h *EC2Handler) ListVpcsHandler(w http.ResponseWriter, r *http.Request) {
+	vpcs, err := h.Network.ListVpcs(r.URL.Query().Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(vpcs); err != nil {
+		http.Error(w, "Failed to encode VPCs to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ListSubnetsHandler lists subnets with free IP counts, optionally of one VPC
+func (h *EC2Handler) ListSubnetsHandler(w http.ResponseWriter, r *http.Request) {
+	subnets, err := h.Network.ListSubnets(r.URL.Query().Get("region"), r.URL.Query().Get("vpcId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(subnets); err != nil {
+		http.Error(w, "Failed to encode subnets to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ListRouteTablesHandler lists route tables, optionally of one VPC
+func (h *EC2Handler) ListRouteTablesHandler(w http.ResponseWriter, r *http.Request) {
+	routeTables, err := h.Network.ListRouteTables(r.URL.Query().Get("region"), r.URL.Query().Get("vpcId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(routeTables); err != nil {
+		http.Error(w, "Failed to encode route tables to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ListGatewaysHandler lists internet and NAT gateways, optionally of one VPC
+func (h *EC2Handler) ListGatewaysHandler(w http.ResponseWriter, r *http.Request) {
+	region, vpcID := r.URL.Query().Get("region"), r.URL.Query().Get("vpcId")
+
+	internetGateways, err := h.Network.ListInternetGateways(region, vpcID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	natGateways, err := h.Network.ListNatGateways(region, vpcID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"internet_gateways": internetGateways,
+		"nat_gateways":      natGateways,
+	})
+}
+
+// ListNetworkInterfacesHandler lists network interfaces, optionally of one VPC or subnet
+func (h *EC2Handler) ListNetworkInterfacesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	interfaces, err := h.Network.ListNetworkInterfaces(query.Get("region"), query.Get("vpcId"), query.Get("subnetId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(interfaces); err != nil {
+		http.Error(w, "Failed to encode network interfaces to JSON", http.StatusInternalServerError)
+	}
+}
+
+// NetworkTopologyHandler returns the VPC → subnet → instance graph as nodes and edges
+func (h *EC2Handler) NetworkTopologyHandler(w http.ResponseWriter, r *http.Request) {
+	graph, err := h.Network.Topology(r.URL.Query().Get("region"), r.URL.Query().Get("vpcId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(graph); err != nil {
+		http.Error(w, "Failed to encode topology to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ListElasticIPsHandler lists the Elastic IPs of a region
+func (h *EC2Handler) ListElasticIPsHandler(w http.ResponseWriter, r *http.Request) {
+	addresses, err := h.Service.ListElasticIPs(r.URL.Query().Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(addresses); err != nil {
+		http.Error(w, "Failed to encode Elastic IPs to JSON", http.StatusInternalServerError)
+	}
+}
+
+// AllocateElasticIPHandler allocates a new Elastic IP
+func (h *EC2Handler) AllocateElasticIPHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.AllocateElasticIPInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	address, err := h.Service.AllocateElasticIP(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(address)
+}
+
+// AssociateElasticIPHandler associates an Elastic IP with an instance or network interface
+func (h *EC2Handler) AssociateElasticIPHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.AssociateElasticIPInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	associationID, err := h.Service.AssociateElasticIP(input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":        "Elastic IP associated successfully",
+		"allocation_id":  input.AllocationID,
+		"association_id": associationID,
+	})
+}
+
+// DisassociateElasticIPHandler disassociates an Elastic IP by associationId or allocationId
+func (h *EC2Handler) DisassociateElasticIPHandler(w http.ResponseWriter, r *http.Request) {
+	allocationID := r.URL.Query().Get("allocationId")
+	associationID := r.URL.Query().Get("associationId")
+	if allocationID == "" && associationID == "" {
+		http.Error(w, "Allocation ID or association ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DisassociateElasticIP(r.URL.Query().Get("region"), allocationID, associationID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Elastic IP disassociated successfully",
+	})
+}
+
+// ReleaseElasticIPHandler releases a disassociated Elastic IP
+func (h *EC2Handler) ReleaseElasticIPHandler(w http.ResponseWriter, r *http.Request) {
+	allocationID := r.URL.Query().Get("allocationId")
+	if allocationID == "" {
+		http.Error(w, "Allocation ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.ReleaseElasticIP(r.URL.Query().Get("region"), allocationID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":       "Elastic IP released successfully",
+		"allocation_id": allocationID,
+	})
+}
+
+// UnattachedElasticIPsHandler reports Elastic IPs that are billed without being associated
+func (h *EC2Handler) UnattachedElasticIPsHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := h.Service.FindUnattachedElasticIPs(r.URL.Query().Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode report to JSON", http.StatusInternalServerError)
+	}
+}
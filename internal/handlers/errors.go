@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/turaneminli/go_backend_aws/internal/services"
+)
+
+// writeServiceError responds with 400 and the list of problems for validation errors,
+// with 404 for missing schedules and with 500 for anything else
+func writeServiceError(w http.ResponseWriter, err error) {
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErr)
+		return
+	}
+	if errors.Is(err, services.ErrScheduleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -2,18 +2,36 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/turaneminli/go_backend_aws/internal/services"
 )
 
+const (
+	// maxSelectRequestSize bounds the body of a select request, which carries only the query and its options
+	maxSelectRequestSize = 64 * 1024
+	// selectErrorTrailer carries an error that stops a select after rows have been streamed
+	selectErrorTrailer = "X-Select-Error"
+	// maxBucketPolicySize is the largest bucket policy S3 accepts
+	maxBucketPolicySize = 20 * 1024
+)
+
 type S3Handler struct {
-	Service *services.S3Service
+	Service    *services.S3Service
+	CloudWatch *services.CloudWatchService
 }
 
-// ListBucketsHandler handles the API request to get the list of buckets
+// ListBucketsHandler handles the API request to get the list of buckets.
+// Pass refresh=true to bypass the cached listing.
 func (h *S3Handler) ListBucketsHandler(w http.ResponseWriter, r *http.Request) {
-	buckets, err := h.Service.ListBuckets()
+	refresh := r.URL.Query().Get("refresh") == "true"
+
+	buckets, err := h.Service.ListBuckets(refresh)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -27,3 +45,856 @@ func (h *S3Handler) ListBucketsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to encode buckets to JSON", http.StatusInternalServerError)
 	}
 }
+
+// BucketMetricsHandler returns the CloudWatch storage metrics of a bucket
+func (h *S3Handler) BucketMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	region, err := h.Service.BucketRegion(bucket)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	metrics, err := h.CloudWatch.GetS3BucketMetrics(bucket, region)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(metrics); err != nil {
+		http.Error(w, "Failed to encode metrics to JSON", http.StatusInternalServerError)
+	}
+}
+
+// StartBucketScanHandler starts a deep scan of a bucket as a background job
+func (h *S3Handler) StartBucketScanHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.Service.StartBucketScan(bucket, r.URL.Query().Get("prefix"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// ListJobsHandler returns all background S3 jobs
+func (h *S3Handler) ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.Service.Jobs.List()); err != nil {
+		http.Error(w, "Failed to encode jobs to JSON", http.StatusInternalServerError)
+	}
+}
+
+// JobStatusHandler returns the progress or result of a background S3 job
+func (h *S3Handler) JobStatusHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := r.URL.Query().Get("jobId")
+	if jobID == "" {
+		http.Error(w, "jobId query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	job, ok := h.Service.Jobs.Get(jobID)
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		http.Error(w, "Failed to encode job to JSON", http.StatusInternalServerError)
+	}
+}
+
+// CancelJobHandler cancels a running background S3 job
+func (h *S3Handler) CancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := r.URL.Query().Get("jobId")
+	if jobID == "" {
+		http.Error(w, "jobId query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.Jobs.Cancel(jobID); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Job cancelled successfully",
+		"job_id":  jobID,
+	})
+}
+
+// ListObjectVersionsHandler lists the versions and delete markers of objects under a prefix
+func (h *S3Handler) ListObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket := query.Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := h.Service.ListObjectVersions(bucket, query.Get("prefix"), query.Get("keyMarker"), query.Get("versionIdMarker"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(versions); err != nil {
+		http.Error(w, "Failed to encode object versions to JSON", http.StatusInternalServerError)
+	}
+}
+
+// DownloadObjectHandler streams an object, or a specific version of it, to the client
+func (h *S3Handler) DownloadObjectHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket, key := query.Get("bucket"), query.Get("key")
+	if bucket == "" || key == "" {
+		http.Error(w, "bucket and key query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	object, err := h.Service.GetObjectVersion(bucket, key, query.Get("versionId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer object.Body.Close()
+
+	if object.ContentType != "" {
+		w.Header().Set("Content-Type", object.ContentType)
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(object.ContentLength, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(key)))
+	io.Copy(w, object.Body)
+}
+
+// RestoreObjectVersionHandler copies a previous version over the current one
+func (h *S3Handler) RestoreObjectVersionHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket, key, versionID := query.Get("bucket"), query.Get("key"), query.Get("versionId")
+	if bucket == "" || key == "" || versionID == "" {
+		http.Error(w, "bucket, key and versionId query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	newVersionID, err := h.Service.RestoreObjectVersion(bucket, key, versionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":    "Object version restored successfully",
+		"key":        key,
+		"version_id": newVersionID,
+	})
+}
+
+// UndeleteObjectHandler removes the delete marker hiding an object
+func (h *S3Handler) UndeleteObjectHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket, key := query.Get("bucket"), query.Get("key")
+	if bucket == "" || key == "" {
+		http.Error(w, "bucket and key query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	markerID, err := h.Service.UndeleteObject(bucket, key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":          "Object undeleted successfully",
+		"key":              key,
+		"delete_marker_id": markerID,
+	})
+}
+
+// GetLifecycleHandler returns the lifecycle rules of a bucket
+func (h *S3Handler) GetLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	config, err := h.Service.GetLifecycleConfiguration(bucket)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(config); err != nil {
+		http.Error(w, "Failed to encode lifecycle configuration to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ValidateLifecycleHandler checks lifecycle rules without writing them
+func (h *S3Handler) ValidateLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	var config services.LifecycleConfiguration
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	problems := services.ValidateLifecycleConfiguration(config)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"valid":    len(problems) == 0,
+		"problems": problems,
+	})
+}
+
+// PutLifecycleHandler validates and replaces the lifecycle rules of a bucket
+func (h *S3Handler) PutLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	var config services.LifecycleConfiguration
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.PutLifecycleConfiguration(bucket, config); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Lifecycle configuration updated successfully",
+		"bucket":  bucket,
+	})
+}
+
+// DryRunLifecycleHandler reports which existing objects a lifecycle rule would act on
+func (h *S3Handler) DryRunLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	var rule services.LifecycleRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Service.DryRunLifecycleRule(bucket, rule)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "Failed to encode dry run result to JSON", http.StatusInternalServerError)
+	}
+}
+
+// StartSyncHandler starts a background job mirroring a source prefix to a destination prefix.
+// Progress and failures are reported through the job status endpoint.
+func (h *S3Handler) StartSyncHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.SyncInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.Service.StartSync(input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// BulkDeleteHandler deletes many objects given keys, a prefix or an uploaded CSV manifest
+func (h *S3Handler) BulkDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	input, err := decodeBulkInput(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.Service.BulkDelete(input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode bulk delete report to JSON", http.StatusInternalServerError)
+	}
+}
+
+// BulkCopyHandler copies many objects given keys, a prefix or an uploaded CSV manifest
+func (h *S3Handler) BulkCopyHandler(w http.ResponseWriter, r *http.Request) {
+	input, err := decodeBulkInput(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.Service.BulkCopy(input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode bulk copy report to JSON", http.StatusInternalServerError)
+	}
+}
+
+// decodeBulkInput reads a bulk request either from a JSON body, or from a multipart
+// upload with a "manifest" CSV file and the remaining options as query parameters
+func decodeBulkInput(r *http.Request) (services.BulkObjectsInput, error) {
+	var input services.BulkObjectsInput
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return input, fmt.Errorf("Invalid request payload")
+		}
+		return input, nil
+	}
+
+	query := r.URL.Query()
+	input.Bucket = query.Get("bucket")
+	input.DestinationBucket = query.Get("destinationBucket")
+	input.DestinationPrefix = query.Get("destinationPrefix")
+
+	file, _, err := r.FormFile("manifest")
+	if err != nil {
+		return input, fmt.Errorf("manifest file is required")
+	}
+	defer file.Close()
+
+	input.Keys, err = services.ParseKeyManifest(file)
+	return input, err
+}
+
+// ListMultipartUploadsHandler lists in-progress multipart uploads of a bucket, or of all buckets
+func (h *S3Handler) ListMultipartUploadsHandler(w http.ResponseWriter, r *http.Request) {
+	uploads, err := h.Service.ListMultipartUploads(r.URL.Query().Get("bucket"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(uploads); err != nil {
+		http.Error(w, "Failed to encode multipart uploads to JSON", http.StatusInternalServerError)
+	}
+}
+
+// AbortMultipartUploadsHandler aborts multipart uploads older than olderThanDays.
+// Dry run is the default; pass dryRun=false to actually abort.
+func (h *S3Handler) AbortMultipartUploadsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	olderThanDays, err := strconv.ParseFloat(query.Get("olderThanDays"), 64)
+	if err != nil {
+		http.Error(w, "olderThanDays query parameter must be a number", http.StatusBadRequest)
+		return
+	}
+	dryRun := query.Get("dryRun") != "false"
+
+	report, err := h.Service.AbortStaleMultipartUploads(query.Get("bucket"), olderThanDays, dryRun)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode cleanup report to JSON", http.StatusInternalServerError)
+	}
+}
+
+// GetBucketPolicyHandler returns the policy document of a bucket
+func (h *S3Handler) GetBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := h.Service.GetBucketPolicy(bucket)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if policy == nil {
+		http.Error(w, "Bucket has no policy", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(policy)
+}
+
+// PreviewBucketPolicyHandler validates a proposed policy and diffs it against the current one
+func (h *S3Handler) PreviewBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBucketPolicySize))
+	if err != nil {
+		http.Error(w, "Policy exceeds 20KB", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.Service.PreviewBucketPolicy(bucket, policy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		http.Error(w, "Failed to encode policy preview to JSON", http.StatusInternalServerError)
+	}
+}
+
+// PutBucketPolicyHandler validates and replaces the policy of a bucket
+func (h *S3Handler) PutBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBucketPolicySize))
+	if err != nil {
+		http.Error(w, "Policy exceeds 20KB", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.Service.PutBucketPolicy(bucket, policy)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Bucket policy updated successfully",
+		"bucket":  bucket,
+		"diff":    diff,
+	})
+}
+
+// DeleteBucketPolicyHandler removes the policy of a bucket
+func (h *S3Handler) DeleteBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteBucketPolicy(bucket); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Bucket policy deleted successfully",
+		"bucket":  bucket,
+	})
+}
+
+// GetBucketCORSHandler returns the CORS rules of a bucket
+func (h *S3Handler) GetBucketCORSHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	config, err := h.Service.GetBucketCORS(bucket)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(config); err != nil {
+		http.Error(w, "Failed to encode CORS configuration to JSON", http.StatusInternalServerError)
+	}
+}
+
+// PreviewBucketCORSHandler validates proposed CORS rules and diffs them against the current ones
+func (h *S3Handler) PreviewBucketCORSHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	var config services.CORSConfiguration
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.Service.PreviewBucketCORS(bucket, config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		http.Error(w, "Failed to encode CORS preview to JSON", http.StatusInternalServerError)
+	}
+}
+
+// PutBucketCORSHandler validates and replaces the CORS rules of a bucket
+func (h *S3Handler) PutBucketCORSHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	var config services.CORSConfiguration
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.Service.PutBucketCORS(bucket, config)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "CORS configuration updated successfully",
+		"bucket":  bucket,
+		"diff":    diff,
+	})
+}
+
+// DeleteBucketCORSHandler removes the CORS configuration of a bucket
+func (h *S3Handler) DeleteBucketCORSHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteBucketCORS(bucket); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "CORS configuration deleted successfully",
+		"bucket":  bucket,
+	})
+}
+
+// HeadObjectHandler returns the head metadata of an object
+func (h *S3Handler) HeadObjectHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket, key := query.Get("bucket"), query.Get("key")
+	if bucket == "" || key == "" {
+		http.Error(w, "bucket and key query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	metadata, err := h.Service.HeadObject(bucket, key, query.Get("versionId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(metadata); err != nil {
+		http.Error(w, "Failed to encode object metadata to JSON", http.StatusInternalServerError)
+	}
+}
+
+// UpdateObjectMetadataHandler edits user metadata and content headers of an object
+func (h *S3Handler) UpdateObjectMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket, key := query.Get("bucket"), query.Get("key")
+	if bucket == "" || key == "" {
+		http.Error(w, "bucket and key query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	var update services.ObjectMetadataUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	metadata, err := h.Service.UpdateObjectMetadata(bucket, key, update)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(metadata); err != nil {
+		http.Error(w, "Failed to encode object metadata to JSON", http.StatusInternalServerError)
+	}
+}
+
+// ChangeStorageClassHandler moves an object to another storage class
+func (h *S3Handler) ChangeStorageClassHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket, key, storageClass := query.Get("bucket"), query.Get("key"), query.Get("storageClass")
+	if bucket == "" || key == "" || storageClass == "" {
+		http.Error(w, "bucket, key and storageClass query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	metadata, err := h.Service.ChangeStorageClass(bucket, key, storageClass)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(metadata); err != nil {
+		http.Error(w, "Failed to encode object metadata to JSON", http.StatusInternalServerError)
+	}
+}
+
+// GetObjectTagsHandler returns the tags of an object
+func (h *S3Handler) GetObjectTagsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket, key := query.Get("bucket"), query.Get("key")
+	if bucket == "" || key == "" {
+		http.Error(w, "bucket and key query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.Service.GetObjectTags(bucket, key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tags); err != nil {
+		http.Error(w, "Failed to encode object tags to JSON", http.StatusInternalServerError)
+	}
+}
+
+// PutObjectTagsHandler replaces the tags of an object
+func (h *S3Handler) PutObjectTagsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket, key := query.Get("bucket"), query.Get("key")
+	if bucket == "" || key == "" {
+		http.Error(w, "bucket and key query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	var tags map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&tags); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.PutObjectTags(bucket, key, tags); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Object tags updated successfully",
+		"key":     key,
+	})
+}
+
+// PreviewObjectHandler renders a size-capped preview of an object's content
+func (h *S3Handler) PreviewObjectHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bucket, key := query.Get("bucket"), query.Get("key")
+	if bucket == "" || key == "" {
+		http.Error(w, "bucket and key query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	var maxBytes int64
+	if value := query.Get("bytes"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "bytes query parameter must be a positive number", http.StatusBadRequest)
+			return
+		}
+		maxBytes = parsed
+	}
+
+	preview, err := h.Service.PreviewObject(bucket, key, maxBytes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		http.Error(w, "Failed to encode object preview to JSON", http.StatusInternalServerError)
+	}
+}
+
+// SelectObjectHandler runs a SQL filter over a CSV or JSON-lines object and streams
+// matching rows back as NDJSON. A failure after the first row is reported in the
+// X-Select-Error trailer.
+func (h *S3Handler) SelectObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.SelectInput
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSelectRequestSize)).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	started := false
+
+	err := h.Service.SelectObject(r.Context(), input, func(line []byte) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Trailer", selectErrorTrailer)
+			started = true
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	if err == nil {
+		return
+	}
+	if !started {
+		writeServiceError(w, err)
+		return
+	}
+	// Once rows have been streamed the status can no longer change, so the error is sent in a
+	// trailer, out of band, where it cannot be mistaken for a row
+	w.Header().Set(selectErrorTrailer, strings.Join(strings.Fields(err.Error()), " "))
+}
+
+// CreateSnapshotHandler starts capturing a snapshot of a bucket listing as a background job
+func (h *S3Handler) CreateSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		http.Error(w, "bucket query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.Service.StartSnapshot(bucket, r.URL.Query().Get("prefix"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// ListSnapshotsHandler lists stored bucket snapshots, optionally for one bucket
+func (h *S3Handler) ListSnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	snapshots, err := h.Service.Snapshots.List(r.URL.Query().Get("bucket"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshots); err != nil {
+		http.Error(w, "Failed to encode snapshots to JSON", http.StatusInternalServerError)
+	}
+}
+
+// DeleteSnapshotHandler removes a stored snapshot
+func (h *S3Handler) DeleteSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.Snapshots.Delete(id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Snapshot deleted successfully",
+		"id":      id,
+	})
+}
+
+// DiffSnapshotsHandler shows the objects added, removed and modified between two snapshots
+func (h *S3Handler) DiffSnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		http.Error(w, "from and to query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.Service.Snapshots.Diff(from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		http.Error(w, "Failed to encode snapshot diff to JSON", http.StatusInternalServerError)
+	}
+}
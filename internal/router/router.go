@@ -14,9 +14,9 @@ func NewRouter(ec2Handler *handlers.EC2Handler, cloudWatchHandler *handlers.Clou
 
 	// CORS configuration
 	corsConfig := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},                             // React app URL (adjust as needed)
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},        // Methods allowed
-		AllowedHeaders:   []string{"Content-Type", "Authorization"}, // Allowed headers
+		AllowedOrigins:   []string{"*"},                                       // React app URL (adjust as needed)
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}, // Methods allowed
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},           // Allowed headers
 		AllowCredentials: true,
 		Debug:            true, // Enable debug to log CORS issues in the server logs
 	})
@@ -27,20 +27,117 @@ func NewRouter(ec2Handler *handlers.EC2Handler, cloudWatchHandler *handlers.Clou
 	// EC2 Routes
 	r.Get("/regions", ec2Handler.ListRegionsHandler)
 	r.Post("/instances/launch", ec2Handler.LaunchInstanceHandler)
+	r.Post("/instances/launch/preflight", ec2Handler.PreflightLaunchHandler)
 	r.Post("/instances/stop", ec2Handler.StopInstanceByIdHandler)
 	r.Post("/instances/start", ec2Handler.StartInstanceByIdHandler)
 	r.Post("/instances/reboot", ec2Handler.RebootInstanceByIdHandler)
 	r.Post("/instances/terminate", ec2Handler.TerminateInstanceByIdHandler)
 	r.Get("/instances/status", ec2Handler.ListRunningInstancesStatusHandler)
 	r.Get("/instances/detail", ec2Handler.InstanceDetailHandler)
+	r.Get("/instances/idle", ec2Handler.IdleInstancesHandler)
+
+	r.Get("/instance-types", ec2Handler.ListInstanceTypesHandler)
+	r.Get("/spot-prices", ec2Handler.SpotPriceHistoryHandler)
+
+	r.Get("/amis", ec2Handler.SearchImagesHandler)
+	r.Post("/amis", ec2Handler.CreateImageHandler)
+	r.Get("/amis/latest", ec2Handler.LatestPublicImagesHandler)
+	r.Get("/amis/detail", ec2Handler.ImageDetailHandler)
+	r.Post("/amis/deregister", ec2Handler.DeregisterImageHandler)
+	r.Post("/amis/copy", ec2Handler.CopyImageHandler)
+
+	r.Get("/volumes", ec2Handler.ListVolumesHandler)
+	r.Post("/volumes", ec2Handler.CreateVolumeHandler)
+	r.Delete("/volumes", ec2Handler.DeleteVolumeHandler)
+	r.Get("/volumes/detail", ec2Handler.VolumeDetailHandler)
+	r.Post("/volumes/attach", ec2Handler.AttachVolumeHandler)
+	r.Post("/volumes/detach", ec2Handler.DetachVolumeHandler)
+	r.Post("/volumes/modify", ec2Handler.ModifyVolumeHandler)
+	r.Get("/volumes/unused", ec2Handler.UnusedStorageHandler)
+	r.Get("/snapshots", ec2Handler.ListSnapshotsHandler)
+	r.Post("/snapshots", ec2Handler.CreateSnapshotHandler)
+	r.Delete("/snapshots", ec2Handler.DeleteSnapshotHandler)
+
+	r.Get("/schedules", ec2Handler.ListSchedulesHandler)
+	r.Post("/schedules", ec2Handler.CreateScheduleHandler)
+	r.Put("/schedules", ec2Handler.UpdateScheduleHandler)
+	r.Delete("/schedules", ec2Handler.DeleteScheduleHandler)
+	r.Get("/schedules/history", ec2Handler.ScheduleHistoryHandler)
+
+	r.Get("/launch-templates", ec2Handler.ListLaunchTemplatesHandler)
+	r.Get("/launch-templates/versions", ec2Handler.ListLaunchTemplateVersionsHandler)
+	r.Post("/launch-templates/launch", ec2Handler.LaunchFromTemplateHandler)
+	r.Post("/launch-templates/from-instance", ec2Handler.CreateTemplateFromInstanceHandler)
 
 	r.Get("/security-groups", ec2Handler.ListSecurityGroupsHandler)
+	r.Post("/security-groups", ec2Handler.CreateSecurityGroupHandler)
+	r.Delete("/security-groups", ec2Handler.DeleteSecurityGroupHandler)
+	r.Get("/security-groups/detail", ec2Handler.SecurityGroupDetailHandler)
+	r.Post("/security-groups/rules", ec2Handler.AuthorizeRulesHandler)
+	r.Post("/security-groups/rules/revoke", ec2Handler.RevokeRulesHandler)
+	r.Get("/security-groups/findings", ec2Handler.SecurityGroupFindingsHandler)
+
+	r.Get("/key-pairs", ec2Handler.ListKeyPairsHandler)
+	r.Post("/key-pairs", ec2Handler.CreateKeyPairHandler)
+	r.Delete("/key-pairs", ec2Handler.DeleteKeyPairHandler)
+	r.Post("/key-pairs/import", ec2Handler.ImportKeyPairHandler)
+
+	r.Get("/network/vpcs", ec2Handler.ListVpcsHandler)
+	r.Get("/network/subnets", ec2Handler.ListSubnetsHandler)
+	r.Get("/network/route-tables", ec2Handler.ListRouteTablesHandler)
+	r.Get("/network/gateways", ec2Handler.ListGatewaysHandler)
+	r.Get("/network/interfaces", ec2Handler.ListNetworkInterfacesHandler)
+	r.Get("/network/topology", ec2Handler.NetworkTopologyHandler)
+
+	r.Get("/elastic-ips", ec2Handler.ListElasticIPsHandler)
+	r.Post("/elastic-ips", ec2Handler.AllocateElasticIPHandler)
+	r.Post("/elastic-ips/associate", ec2Handler.AssociateElasticIPHandler)
+	r.Post("/elastic-ips/disassociate", ec2Handler.DisassociateElasticIPHandler)
+	r.Post("/elastic-ips/release", ec2Handler.ReleaseElasticIPHandler)
+	r.Get("/elastic-ips/unattached", ec2Handler.UnattachedElasticIPsHandler)
 
 	// CloudWatch Routes
 	r.Get("/cloudwatch/metrics", cloudWatchHandler.GetEC2MetricsHandler)
 
 	// S3 Routes
 	r.Get("/s3/buckets", s3Handler.ListBucketsHandler)
+	r.Get("/s3/buckets/metrics", s3Handler.BucketMetricsHandler)
+	r.Post("/s3/buckets/scan", s3Handler.StartBucketScanHandler)
+	r.Get("/s3/buckets/lifecycle", s3Handler.GetLifecycleHandler)
+	r.Post("/s3/buckets/lifecycle", s3Handler.PutLifecycleHandler)
+	r.Post("/s3/buckets/lifecycle/validate", s3Handler.ValidateLifecycleHandler)
+	r.Post("/s3/buckets/lifecycle/dry-run", s3Handler.DryRunLifecycleHandler)
+	r.Get("/s3/buckets/policy", s3Handler.GetBucketPolicyHandler)
+	r.Put("/s3/buckets/policy", s3Handler.PutBucketPolicyHandler)
+	r.Delete("/s3/buckets/policy", s3Handler.DeleteBucketPolicyHandler)
+	r.Post("/s3/buckets/policy/preview", s3Handler.PreviewBucketPolicyHandler)
+	r.Get("/s3/buckets/cors", s3Handler.GetBucketCORSHandler)
+	r.Put("/s3/buckets/cors", s3Handler.PutBucketCORSHandler)
+	r.Delete("/s3/buckets/cors", s3Handler.DeleteBucketCORSHandler)
+	r.Post("/s3/buckets/cors/preview", s3Handler.PreviewBucketCORSHandler)
+	r.Get("/s3/objects/versions", s3Handler.ListObjectVersionsHandler)
+	r.Get("/s3/objects/download", s3Handler.DownloadObjectHandler)
+	r.Post("/s3/objects/restore-version", s3Handler.RestoreObjectVersionHandler)
+	r.Post("/s3/objects/undelete", s3Handler.UndeleteObjectHandler)
+	r.Get("/s3/objects/head", s3Handler.HeadObjectHandler)
+	r.Get("/s3/objects/preview", s3Handler.PreviewObjectHandler)
+	r.Post("/s3/objects/select", s3Handler.SelectObjectHandler)
+	r.Post("/s3/objects/metadata", s3Handler.UpdateObjectMetadataHandler)
+	r.Post("/s3/objects/storage-class", s3Handler.ChangeStorageClassHandler)
+	r.Get("/s3/objects/tags", s3Handler.GetObjectTagsHandler)
+	r.Put("/s3/objects/tags", s3Handler.PutObjectTagsHandler)
+	r.Post("/s3/objects/bulk-delete", s3Handler.BulkDeleteHandler)
+	r.Post("/s3/objects/bulk-copy", s3Handler.BulkCopyHandler)
+	r.Post("/s3/sync", s3Handler.StartSyncHandler)
+	r.Get("/s3/multipart-uploads", s3Handler.ListMultipartUploadsHandler)
+	r.Post("/s3/multipart-uploads/abort", s3Handler.AbortMultipartUploadsHandler)
+	r.Get("/s3/snapshots", s3Handler.ListSnapshotsHandler)
+	r.Post("/s3/snapshots", s3Handler.CreateSnapshotHandler)
+	r.Delete("/s3/snapshots", s3Handler.DeleteSnapshotHandler)
+	r.Get("/s3/snapshots/diff", s3Handler.DiffSnapshotsHandler)
+	r.Get("/s3/jobs", s3Handler.ListJobsHandler)
+	r.Get("/s3/jobs/status", s3Handler.JobStatusHandler)
+	r.Post("/s3/jobs/cancel", s3Handler.CancelJobHandler)
 
 	return r
 }
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// ttlCache is a small concurrency-safe map whose entries expire after a fixed duration
+type ttlCache[V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry[V]
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry[V]),
+	}
+}
+
+// Get returns the cached value for key if it is present and not expired
+func (c *ttlCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key for the cache's TTL
+func (c *ttlCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry[V]{value: value, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// Delete evicts key from the cache
+func (c *ttlCache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
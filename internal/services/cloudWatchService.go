@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -104,3 +106,229 @@ func (s *CloudWatchService) GetEC2Metrics(instanceID string) ([]EC2Metrics, erro
 
 	return ec2Metrics, nil
 }
+
+// BucketStorageMetric holds the stored bytes of a bucket for one storage type
+type BucketStorageMetric struct {
+	StorageType string  `json:"storage_type"`
+	SizeBytes   float64 `json:"size_bytes"`
+	Timestamp   string  `json:"timestamp"`
+}
+
+// BucketMetrics holds the daily storage metrics CloudWatch publishes for a bucket
+type BucketMetrics struct {
+	Bucket          string                `json:"bucket"`
+	Region          string                `json:"region"`
+	TotalSizeBytes  float64               `json:"total_size_bytes"`
+	NumberOfObjects float64               `json:"number_of_objects"`
+	StorageTypes    []BucketStorageMetric `json:"storage_types"`
+}
+
+// GetS3BucketMetrics fetches BucketSizeBytes per storage type and NumberOfObjects for a bucket.
+// S3 publishes these metrics once a day in the bucket's own region.
+func (s *CloudWatchService) GetS3BucketMetrics(bucketName, region string) (*BucketMetrics, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client := s.Client
+	if region != "" && region != s.Client.Options().Region {
+		client = cloudwatch.New(s.Client.Options(), func(o *cloudwatch.Options) {
+			o.Region = region
+		})
+	}
+
+	// Discover which storage types the bucket has size metrics for
+	var storageTypes []string
+	paginator := cloudwatch.NewListMetricsPaginator(client, &cloudwatch.ListMetricsInput{
+		Namespace:  aws.String("AWS/S3"),
+		MetricName: aws.String("BucketSizeBytes"),
+		Dimensions: []types.DimensionFilter{
+			{Name: aws.String("BucketName"), Value: aws.String(bucketName)},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bucket metrics: %v", err)
+		}
+		for _, metric := range page.Metrics {
+			for _, dimension := range metric.Dimensions {
+				if aws.ToString(dimension.Name) == "StorageType" {
+					storageTypes = append(storageTypes, aws.ToString(dimension.Value))
+				}
+			}
+		}
+	}
+
+	queries := []types.MetricDataQuery{
+		bucketMetricQuery("objects", bucketName, "NumberOfObjects", "AllStorageTypes"),
+	}
+	for i, storageType := range storageTypes {
+		queries = append(queries, bucketMetricQuery(fmt.Sprintf("size_%d", i), bucketName, "BucketSizeBytes", storageType))
+	}
+
+	// Storage metrics are daily, so look back a few days to always catch the latest datapoint
+	output, err := client.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{
+		MetricDataQueries: queries,
+		StartTime:         aws.Time(time.Now().Add(-72 * time.Hour)),
+		EndTime:           aws.Time(time.Now()),
+		ScanBy:            types.ScanByTimestampDescending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric data: %v", err)
+	}
+
+	bucketMetrics := &BucketMetrics{
+		Bucket:       bucketName,
+		Region:       region,
+		StorageTypes: []BucketStorageMetric{},
+	}
+	for _, result := range output.MetricDataResults {
+		if len(result.Values) == 0 {
+			continue
+		}
+
+		id := aws.ToString(result.Id)
+		if id == "objects" {
+			bucketMetrics.NumberOfObjects = result.Values[0]
+			continue
+		}
+
+		var index int
+		if _, err := fmt.Sscanf(id, "size_%d", &index); err != nil || index >= len(storageTypes) {
+			continue
+		}
+		bucketMetrics.StorageTypes = append(bucketMetrics.StorageTypes, BucketStorageMetric{
+			StorageType: storageTypes[index],
+			SizeBytes:   result.Values[0],
+			Timestamp:   result.Timestamps[0].Format(time.RFC3339),
+		})
+		bucketMetrics.TotalSizeBytes += result.Values[0]
+	}
+
+	return bucketMetrics, nil
+}
+
+func bucketMetricQuery(id, bucketName, metricName, storageType string) types.MetricDataQuery {
+	return types.MetricDataQuery{
+		Id: aws.String(id),
+		MetricStat: &types.MetricStat{
+			Metric: &types.Metric{
+				Namespace:  aws.String("AWS/S3"),
+				MetricName: aws.String(metricName),
+				Dimensions: []types.Dimension{
+					{Name: aws.String("BucketName"), Value: aws.String(bucketName)},
+					{Name: aws.String("StorageType"), Value: aws.String(storageType)},
+				},
+			},
+			Period: aws.Int32(86400),
+			Stat:   aws.String("Average"),
+		},
+		ReturnData: aws.Bool(true),
+	}
+}
+
+// InstanceUtilization summarizes the CPU and network use of an instance over a window
+type InstanceUtilization struct {
+	InstanceID            string  `json:"instance_id"`
+	PeakCPUPercent        float64 `json:"peak_cpu_percent"`    // highest hourly average
+	AverageCPUPercent     float64 `json:"average_cpu_percent"` // mean of the hourly averages
+	PeakDailyNetworkBytes float64 `json:"peak_daily_network_bytes"`
+	HoursWithData         int     `json:"hours_with_data"`
+}
+
+// instancesPerMetricBatch keeps each GetMetricData call within its datapoint limit
+const instancesPerMetricBatch = 50
+
+// GetInstanceUtilization fetches hourly CPU and daily network totals for instances over the last days days
+func (s *CloudWatchService) GetInstanceUtilization(instanceIDs []string, days int) (map[string]*InstanceUtilization, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	end := time.Now().Truncate(time.Hour)
+	start := end.AddDate(0, 0, -days)
+	utilization := make(map[string]*InstanceUtilization, len(instanceIDs))
+
+	for offset := 0; offset < len(instanceIDs); offset += instancesPerMetricBatch {
+		batch := instanceIDs[offset:min(offset+instancesPerMetricBatch, len(instanceIDs))]
+
+		var queries []types.MetricDataQuery
+		for i, instanceID := range batch {
+			utilization[instanceID] = &InstanceUtilization{InstanceID: instanceID}
+			queries = append(queries,
+				instanceMetricQuery(fmt.Sprintf("cpu_%d", i), instanceID, "CPUUtilization", "Average", 3600),
+				instanceMetricQuery(fmt.Sprintf("in_%d", i), instanceID, "NetworkIn", "Sum", 86400),
+				instanceMetricQuery(fmt.Sprintf("out_%d", i), instanceID, "NetworkOut", "Sum", 86400),
+			)
+		}
+
+		dailyNetwork := make(map[string]map[time.Time]float64)
+		paginator := cloudwatch.NewGetMetricDataPaginator(s.Client, &cloudwatch.GetMetricDataInput{
+			MetricDataQueries: queries,
+			StartTime:         aws.Time(start),
+			EndTime:           aws.Time(end),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get metric data: %v", err)
+			}
+
+			for _, result := range page.MetricDataResults {
+				metric, position, _ := strings.Cut(aws.ToString(result.Id), "_")
+				index, err := strconv.Atoi(position)
+				if err != nil || index >= len(batch) {
+					continue
+				}
+				instance := utilization[batch[index]]
+
+				if metric == "cpu" {
+					for _, value := range result.Values {
+						instance.PeakCPUPercent = max(instance.PeakCPUPercent, value)
+						instance.AverageCPUPercent += value
+						instance.HoursWithData++
+					}
+					continue
+				}
+
+				// NetworkIn and NetworkOut are added up per day
+				if dailyNetwork[instance.InstanceID] == nil {
+					dailyNetwork[instance.InstanceID] = make(map[time.Time]float64)
+				}
+				for i, timestamp := range result.Timestamps {
+					dailyNetwork[instance.InstanceID][timestamp] += result.Values[i]
+				}
+			}
+		}
+
+		for instanceID, daily := range dailyNetwork {
+			for _, total := range daily {
+				utilization[instanceID].PeakDailyNetworkBytes = max(utilization[instanceID].PeakDailyNetworkBytes, total)
+			}
+		}
+	}
+
+	for _, instance := range utilization {
+		if instance.HoursWithData > 0 {
+			instance.AverageCPUPercent /= float64(instance.HoursWithData)
+		}
+	}
+	return utilization, nil
+}
+
+func instanceMetricQuery(id, instanceID, metricName, stat string, period int32) types.MetricDataQuery {
+	return types.MetricDataQuery{
+		Id: aws.String(id),
+		MetricStat: &types.MetricStat{
+			Metric: &types.Metric{
+				Namespace:  aws.String("AWS/EC2"),
+				MetricName: aws.String(metricName),
+				Dimensions: []types.Dimension{
+					{Name: aws.String("InstanceId"), Value: aws.String(instanceID)},
+				},
+			},
+			Period: aws.Int32(period),
+			Stat:   aws.String(stat),
+		},
+		ReturnData: aws.Bool(true),
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"sort"
+)
+
+// DiffEntry is one identifiable element of a document (a policy statement, a CORS rule)
+// flattened into sets of values per field
+type DiffEntry struct {
+	ID     string              `json:"id"`
+	Fields map[string][]string `json:"fields"`
+}
+
+// FieldChange lists the values added to and removed from one field of an entry
+type FieldChange struct {
+	Field   string   `json:"field"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// EntryChange lists the changed fields of an entry present in both documents
+type EntryChange struct {
+	ID      string        `json:"id"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// DocumentDiff is a semantic, order-insensitive comparison of two documents
+type DocumentDiff struct {
+	Added     []DiffEntry   `json:"added"`
+	Removed   []DiffEntry   `json:"removed"`
+	Changed   []EntryChange `json:"changed"`
+	Unchanged int           `json:"unchanged"`
+}
+
+// diffEntries matches entries by ID and compares their fields as sets
+func diffEntries(current, proposed []DiffEntry) *DocumentDiff {
+	diff := &DocumentDiff{
+		Added:   []DiffEntry{},
+		Removed: []DiffEntry{},
+		Changed: []EntryChange{},
+	}
+
+	currentByID := make(map[string]DiffEntry)
+	for _, entry := range current {
+		currentByID[entry.ID] = entry
+	}
+	proposedByID := make(map[string]DiffEntry)
+	for _, entry := range proposed {
+		proposedByID[entry.ID] = entry
+	}
+
+	for _, entry := range proposed {
+		before, ok := currentByID[entry.ID]
+		if !ok {
+			diff.Added = append(diff.Added, entry)
+			continue
+		}
+
+		changes := diffFields(before.Fields, entry.Fields)
+		if len(changes) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, EntryChange{ID: entry.ID, Changes: changes})
+	}
+
+	for _, entry := range current {
+		if _, ok := proposedByID[entry.ID]; !ok {
+			diff.Removed = append(diff.Removed, entry)
+		}
+	}
+
+	return diff
+}
+
+func diffFields(before, after map[string][]string) []FieldChange {
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	var changes []FieldChange
+	for _, name := range sortedNames {
+		added, removed := diffSets(before[name], after[name])
+		if len(added) > 0 || len(removed) > 0 {
+			changes = append(changes, FieldChange{Field: name, Added: added, Removed: removed})
+		}
+	}
+	return changes
+}
+
+func diffSets(before, after []string) (added, removed []string) {
+	inBefore := make(map[string]bool)
+	for _, value := range before {
+		inBefore[value] = true
+	}
+	inAfter := make(map[string]bool)
+	for _, value := range after {
+		inAfter[value] = true
+		if !inBefore[value] {
+			added = append(added, value)
+		}
+	}
+	for _, value := range before {
+		if !inAfter[value] {
+			removed = append(removed, value)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// PublicIPv4HourlyPrice is the USD price AWS charges per hour for each public IPv4 address
+const PublicIPv4HourlyPrice = 0.005
+
+// ElasticIP describes an Elastic IP address and what it is associated with
+type ElasticIP struct {
+	AllocationID       string            `json:"allocationId"`
+	PublicIP           string            `json:"publicIp"`
+	Name               string            `json:"name"`
+	Domain             string            `json:"domain"`
+	AssociationID      string            `json:"associationId,omitempty"`
+	InstanceID         string            `json:"instanceId,omitempty"`
+	NetworkInterfaceID string            `json:"networkInterfaceId,omitempty"`
+	PrivateIP          string            `json:"privateIp,omitempty"`
+	NetworkBorderGroup string            `json:"networkBorderGroup"`
+	PublicIPv4Pool     string            `json:"publicIpv4Pool"`
+	Tags               map[string]string `json:"tags"`
+}
+
+// AllocateElasticIPInput allocates an Elastic IP in a region
+type AllocateElasticIPInput struct {
+	Region         string            `json:"region"`
+	Name           string            `json:"name"`
+	PublicIPv4Pool string            `json:"publicIpv4Pool"` // defaults to the Amazon pool
+	Tags           map[string]string `json:"tags"`
+}
+
+// AssociateElasticIPInput associates an Elastic IP with an instance or a network interface
+type AssociateElasticIPInput struct {
+	Region             string `json:"region"`
+	AllocationID       string `json:"allocationId"`
+	InstanceID         string `json:"instanceId"`
+	NetworkInterfaceID string `json:"networkInterfaceId"`
+	PrivateIP          string `json:"privateIp"`          // defaults to the primary private address
+	AllowReassociation bool   `json:"allowReassociation"` // move the address if it is already associated
+}
+
+// Validate checks the input
+func (input *AssociateElasticIPInput) Validate() error {
+	var problems []string
+	if input.AllocationID == "" {
+		problems = append(problems, "allocationId is required")
+	}
+	if (input.InstanceID == "") == (input.NetworkInterfaceID == "") {
+		problems = append(problems, "exactly one of instanceId or networkInterfaceId is required")
+	}
+	return validationError(problems)
+}
+
+// UnattachedElasticIPReport lists Elastic IPs that are not associated with anything but are still billed
+type UnattachedElasticIPReport struct {
+	Region               string      `json:"region"`
+	GeneratedAt          string      `json:"generatedAt"`
+	Addresses            []ElasticIP `json:"addresses"`
+	HourlyPrice          float64     `json:"hourlyPrice"` // per address
+	EstimatedMonthlyCost float64     `json:"estimatedMonthlyCost"`
+}
+
+// ListElasticIPs returns the Elastic IPs of a region
+func (s *EC2Service) ListElasticIPs(region string) ([]ElasticIP, error) {
+	return describeElasticIPs(context.TODO(), s.clientForRegion(region), nil)
+}
+
+func describeElasticIPs(ctx context.Context, client *ec2.Client, allocationIDs []string) ([]ElasticIP, error) {
+	output, err := client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{AllocationIds: allocationIDs})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe addresses: %w", err)
+	}
+
+	addresses := make([]ElasticIP, 0, len(output.Addresses))
+	for _, address := range output.Addresses {
+		addresses = append(addresses, toElasticIP(address))
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].PublicIP < addresses[j].PublicIP
+	})
+	return addresses, nil
+}
+
+// AllocateElasticIP allocates a new Elastic IP for use in a VPC
+func (s *EC2Service) AllocateElasticIP(input AllocateElasticIPInput) (*ElasticIP, error) {
+	allocateInput := &ec2.AllocateAddressInput{Domain: types.DomainTypeVpc}
+	if input.PublicIPv4Pool != "" {
+		allocateInput.PublicIpv4Pool = aws.String(input.PublicIPv4Pool)
+	}
+
+	var tags []types.Tag
+	if input.Name != "" {
+		tags = append(tags, types.Tag{Key: aws.String("Name"), Value: aws.String(input.Name)})
+	}
+	for _, key := range sortedKeys(input.Tags) {
+		if key == "Name" && input.Name != "" {
+			continue
+		}
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(input.Tags[key])})
+	}
+	if len(tags) > 0 {
+		allocateInput.TagSpecifications = []types.TagSpecification{{ResourceType: types.ResourceTypeElasticIp, Tags: tags}}
+	}
+
+	output, err := s.clientForRegion(input.Region).AllocateAddress(context.TODO(), allocateInput)
+	if err != nil {
+		return nil, fmt.Errorf("unable to allocate address: %w", err)
+	}
+
+	address := &ElasticIP{
+		AllocationID:       aws.ToString(output.AllocationId),
+		PublicIP:           aws.ToString(output.PublicIp),
+		Domain:             string(output.Domain),
+		NetworkBorderGroup: aws.ToString(output.NetworkBorderGroup),
+		PublicIPv4Pool:     aws.ToString(output.PublicIpv4Pool),
+		Tags:               tagMap(tags),
+	}
+	address.Name = address.Tags["Name"]
+	return address, nil
+}
+
+// AssociateElasticIP associates an Elastic IP with an instance or network interface and returns the association ID
+func (s *EC2Service) AssociateElasticIP(input AssociateElasticIPInput) (string, error) {
+	associateInput := &ec2.AssociateAddressInput{
+		AllocationId:       aws.String(input.AllocationID),
+		AllowReassociation: aws.Bool(input.AllowReassociation),
+	}
+	if input.InstanceID != "" {
+		associateInput.InstanceId = aws.String(input.InstanceID)
+	} else {
+		associateInput.NetworkInterfaceId = aws.String(input.NetworkInterfaceID)
+	}
+	if input.PrivateIP != "" {
+		associateInput.PrivateIpAddress = aws.String(input.PrivateIP)
+	}
+
+	output, err := s.clientForRegion(input.Region).AssociateAddress(context.TODO(), associateInput)
+	if err != nil {
+		return "", fmt.Errorf("unable to associate address: %w", err)
+	}
+	return aws.ToString(output.AssociationId), nil
+}
+
+// DisassociateElasticIP removes the association of an Elastic IP, found by association ID
+// or, when that is empty, by allocation ID
+func (s *EC2Service) DisassociateElasticIP(region, allocationID, associationID string) error {
+	client := s.clientForRegion(region)
+	if associationID == "" {
+		addresses, err := describeElasticIPs(context.TODO(), client, []string{allocationID})
+		if err != nil {
+			return err
+		}
+		if len(addresses) == 0 || addresses[0].AssociationID == "" {
+			return fmt.Errorf("address %s is not associated", allocationID)
+		}
+		associationID = addresses[0].AssociationID
+	}
+
+	_, err := client.DisassociateAddress(context.TODO(), &ec2.DisassociateAddressInput{
+		AssociationId: aws.String(associationID),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to disassociate address: %w", err)
+	}
+	return nil
+}
+
+// ReleaseElasticIP releases an Elastic IP back to AWS; it must be disassociated first
+func (s *EC2Service) ReleaseElasticIP(region, allocationID string) error {
+	_, err := s.clientForRegion(region).ReleaseAddress(context.TODO(), &ec2.ReleaseAddressInput{
+		AllocationId: aws.String(allocationID),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to release address: %w", err)
+	}
+	return nil
+}
+
+// FindUnattachedElasticIPs reports Elastic IPs that are not associated with an instance or network interface
+func (s *EC2Service) FindUnattachedElasticIPs(region string) (*UnattachedElasticIPReport, error) {
+	client := s.clientForRegion(region)
+	addresses, err := describeElasticIPs(context.TODO(), client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UnattachedElasticIPReport{
+		Region:      client.Options().Region,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Addresses:   []ElasticIP{},
+		HourlyPrice: PublicIPv4HourlyPrice,
+	}
+	for _, address := range addresses {
+		if address.AssociationID == "" {
+			report.Addresses = append(report.Addresses, address)
+		}
+	}
+	report.EstimatedMonthlyCost = roundCents(float64(len(report.Addresses)) * PublicIPv4HourlyPrice * HoursPerMonth)
+	return report, nil
+}
+
+// addElasticIPDetails marks instances that hold an Elastic IP and fills in their public IP,
+// which DescribeInstances may leave empty while an instance is stopped.
+// Failures are logged rather than returned so the instance list still loads.
+func (s *EC2Service) addElasticIPDetails(ctx context.Context, instances []InstanceStatus) {
+	if len(instances) == 0 {
+		return
+	}
+
+	addresses, err := describeElasticIPs(ctx, s.Client, nil)
+	if err != nil {
+		log.Printf("unable to describe elastic IPs: %v", err)
+		return
+	}
+
+	byInstance := make(map[string]ElasticIP, len(addresses))
+	for _, address := range addresses {
+		if address.InstanceID != "" {
+			byInstance[address.InstanceID] = address
+		}
+	}
+
+	for i := range instances {
+		address, ok := byInstance[instances[i].ID]
+		if !ok {
+			continue
+		}
+		instances[i].ElasticIPAllocationID = address.AllocationID
+		if instances[i].PublicIP == "" {
+			instances[i].PublicIP = address.PublicIP
+		}
+	}
+}
+
+func toElasticIP(address types.Address) ElasticIP {
+	result := ElasticIP{
+		AllocationID:       aws.ToString(address.AllocationId),
+		PublicIP:           aws.ToString(address.PublicIp),
+		Domain:             string(address.Domain),
+		AssociationID:      aws.ToString(address.AssociationId),
+		InstanceID:         aws.ToString(address.InstanceId),
+		NetworkInterfaceID: aws.ToString(address.NetworkInterfaceId),
+		PrivateIP:          aws.ToString(address.PrivateIpAddress),
+		NetworkBorderGroup: aws.ToString(address.NetworkBorderGroup),
+		PublicIPv4Pool:     aws.ToString(address.PublicIpv4Pool),
+		Tags:               tagMap(address.Tags),
+	}
+	result.Name = result.Tags["Name"]
+	return result
+}
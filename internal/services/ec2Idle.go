@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const (
+	defaultIdleDays       = 14
+	maxIdleDays           = 60
+	defaultIdleCPUPercent = 5
+	defaultIdleNetworkMB  = 5
+)
+
+// instanceSizes lists instance sizes from smallest to largest
+var instanceSizes = []string{"nano", "micro", "small", "medium", "large", "xlarge", "2xlarge", "3xlarge", "4xlarge",
+	"6xlarge", "8xlarge", "9xlarge", "10xlarge", "12xlarge", "16xlarge", "18xlarge", "24xlarge", "32xlarge", "48xlarge"}
+
+// RecommendationService combines EC2, CloudWatch and pricing data into cost recommendations
+type RecommendationService struct {
+	EC2        *EC2Service
+	CloudWatch *CloudWatchService
+	Pricing    *PricingService
+}
+
+// IdleCriteria are the thresholds an instance must stay below to be reported as idle
+type IdleCriteria struct {
+	Days                int     `json:"days"`
+	CPUThresholdPercent float64 `json:"cpu_threshold_percent"` // peak hourly average CPU
+	NetworkThresholdMB  float64 `json:"network_threshold_mb"`  // peak daily network in + out
+}
+
+// IdleSuggestion is a recommended action for an idle instance
+type IdleSuggestion struct {
+	Action                 string  `json:"action"` // stop or downsize
+	TargetInstanceType     string  `json:"target_instance_type,omitempty"`
+	EstimatedMonthlySaving float64 `json:"estimated_monthly_saving"`
+	Reason                 string  `json:"reason"`
+}
+
+// IdleInstance is a running instance that stayed below the idle thresholds
+type IdleInstance struct {
+	InstanceID   string              `json:"instance_id"`
+	Name         string              `json:"name"`
+	InstanceType string              `json:"instance_type"`
+	LaunchTime   string              `json:"launch_time"`
+	HourlyPrice  float64             `json:"hourly_price"`
+	Utilization  InstanceUtilization `json:"utilization"`
+	Suggestions  []IdleSuggestion    `json:"suggestions"`
+}
+
+// IdleInstanceReport lists idle instances and what stopping them would save
+type IdleInstanceReport struct {
+	Region                 string         `json:"region"`
+	GeneratedAt            string         `json:"generated_at"`
+	Criteria               IdleCriteria   `json:"criteria"`
+	InstancesChecked       int            `json:"instances_checked"`
+	Instances              []IdleInstance `json:"instances"`
+	InsufficientData       []string       `json:"insufficient_data"` // running for less than the window or without metrics
+	EstimatedMonthlySaving float64        `json:"estimated_monthly_saving"`
+	Notes                  []string       `json:"notes"`
+}
+
+// FindIdleInstances reports running instances whose CPU and network stayed below the thresholds
+// for the whole window, with suggested actions and estimated savings at Linux on-demand prices
+func (s *RecommendationService) FindIdleInstances(criteria IdleCriteria) (*IdleInstanceReport, error) {
+	if criteria.Days <= 0 {
+		criteria.Days = defaultIdleDays
+	}
+	criteria.Days = min(criteria.Days, maxIdleDays)
+	if criteria.CPUThresholdPercent <= 0 {
+		criteria.CPUThresholdPercent = defaultIdleCPUPercent
+	}
+	if criteria.NetworkThresholdMB <= 0 {
+		criteria.NetworkThresholdMB = defaultIdleNetworkMB
+	}
+
+	statuses, err := s.EC2.GetAllRunningInstancesStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IdleInstanceReport{
+		Region:           s.EC2.Client.Options().Region,
+		GeneratedAt:      time.Now().UTC().Format(time.RFC3339),
+		Criteria:         criteria,
+		Instances:        []IdleInstance{},
+		InsufficientData: []string{},
+		Notes:            []string{"savings are estimated from Linux on-demand prices and exclude EBS storage"},
+	}
+
+	// Only instances that have been running for the whole window can be judged
+	windowStart := time.Now().AddDate(0, 0, -criteria.Days)
+	var candidates []InstanceStatus
+	for _, status := range statuses {
+		if status.State != string(types.InstanceStateNameRunning) {
+			continue
+		}
+		report.InstancesChecked++
+		if launched, err := time.Parse(time.RFC3339, status.LaunchTime); err == nil && launched.After(windowStart) {
+			report.InsufficientData = append(report.InsufficientData, status.ID)
+			continue
+		}
+		candidates = append(candidates, status)
+	}
+	if len(candidates) == 0 {
+		return report, nil
+	}
+
+	instanceIDs := make([]string, len(candidates))
+	for i, status := range candidates {
+		instanceIDs[i] = status.ID
+	}
+	utilization, err := s.CloudWatch.GetInstanceUtilization(instanceIDs, criteria.Days)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Expect hourly CPU datapoints for most of the window before calling an instance idle
+	minimumHours := criteria.Days * 24 * 9 / 10
+	networkThreshold := criteria.NetworkThresholdMB * 1024 * 1024
+	pricingFailed := false
+
+	for _, status := range candidates {
+		usage := utilization[status.ID]
+		if usage == nil || usage.HoursWithData < minimumHours {
+			report.InsufficientData = append(report.InsufficientData, status.ID)
+			continue
+		}
+		if usage.PeakCPUPercent >= criteria.CPUThresholdPercent || usage.PeakDailyNetworkBytes >= networkThreshold {
+			continue
+		}
+
+		idle := IdleInstance{
+			InstanceID:   status.ID,
+			Name:         status.Name,
+			InstanceType: status.InstanceType,
+			LaunchTime:   status.LaunchTime,
+			Utilization:  *usage,
+		}
+
+		price, err := s.Pricing.OnDemandHourlyPrice(ctx, report.Region, status.InstanceType)
+		if err != nil {
+			pricingFailed = true
+		}
+		idle.HourlyPrice = price
+
+		idle.Suggestions = append(idle.Suggestions, IdleSuggestion{
+			Action:                 "stop",
+			EstimatedMonthlySaving: roundCents(price * HoursPerMonth),
+			Reason: fmt.Sprintf("peak CPU %.1f%% and peak daily network %.1f MB over %d days",
+				usage.PeakCPUPercent, usage.PeakDailyNetworkBytes/1024/1024, criteria.Days),
+		})
+		if downsize, ok := s.downsizeSuggestion(ctx, report.Region, status.InstanceType, price); ok {
+			idle.Suggestions = append(idle.Suggestions, downsize)
+		}
+
+		report.EstimatedMonthlySaving += idle.Suggestions[0].EstimatedMonthlySaving
+		report.Instances = append(report.Instances, idle)
+	}
+
+	if pricingFailed {
+		report.Notes = append(report.Notes, "prices could not be retrieved for some instance types; their savings show as 0")
+	}
+	report.EstimatedMonthlySaving = roundCents(report.EstimatedMonthlySaving)
+	sort.Slice(report.Instances, func(i, j int) bool {
+		return report.Instances[i].Suggestions[0].EstimatedMonthlySaving > report.Instances[j].Suggestions[0].EstimatedMonthlySaving
+	})
+	return report, nil
+}
+
+// downsizeSuggestion proposes the next smaller size in the same family that is sold in the region
+func (s *RecommendationService) downsizeSuggestion(ctx context.Context, region, instanceType string, price float64) (IdleSuggestion, bool) {
+	family, size, ok := strings.Cut(instanceType, ".")
+	index := slices.Index(instanceSizes, size)
+	if !ok || index <= 0 {
+		return IdleSuggestion{}, false
+	}
+
+	for i := index - 1; i >= 0 && i >= index-3; i-- {
+		target := family + "." + instanceSizes[i]
+		targetPrice, err := s.Pricing.OnDemandHourlyPrice(ctx, region, target)
+		if err != nil {
+			return IdleSuggestion{}, false
+		}
+		if targetPrice == 0 {
+			continue
+		}
+		return IdleSuggestion{
+			Action:                 "downsize",
+			TargetInstanceType:     target,
+			EstimatedMonthlySaving: roundCents(max(price-targetPrice, 0) * HoursPerMonth),
+			Reason:                 "keeps the instance available at a lower cost if it cannot be stopped",
+		}, true
+	}
+	return IdleSuggestion{}, false
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+const (
+	// maxImageResults caps the number of AMIs returned by a search
+	maxImageResults = 200
+	// maxImageSearchPages caps how many pages of up to 1000 AMIs a search reads
+	maxImageSearchPages = 5
+	// minImageNameLength is the shortest name pattern, wildcards aside, accepted for public catalogues
+	minImageNameLength = 3
+	imageSearchTimeout = 30 * time.Second
+)
+
+// publicImageParameters maps an OS and architecture to the SSM public parameter holding its latest AMI
+var publicImageParameters = map[string]map[string]string{
+	"amazon-linux-2023": {
+		"x86_64": "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64",
+		"arm64":  "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-arm64",
+	},
+	"amazon-linux-2": {
+		"x86_64": "/aws/service/ami-amazon-linux-latest/amzn2-ami-hvm-x86_64-gp2",
+		"arm64":  "/aws/service/ami-amazon-linux-latest/amzn2-ami-hvm-arm64-gp2",
+	},
+	"ubuntu-24.04": {
+		"x86_64": "/aws/service/canonical/ubuntu/server/24.04/stable/current/amd64/hvm/ebs-gp3/ami-id",
+		"arm64":  "/aws/service/canonical/ubuntu/server/24.04/stable/current/arm64/hvm/ebs-gp3/ami-id",
+	},
+	"ubuntu-22.04": {
+		"x86_64": "/aws/service/canonical/ubuntu/server/22.04/stable/current/amd64/hvm/ebs-gp2/ami-id",
+		"arm64":  "/aws/service/canonical/ubuntu/server/22.04/stable/current/arm64/hvm/ebs-gp2/ami-id",
+	},
+	"debian-12": {
+		"x86_64": "/aws/service/debian/release/12/latest/amd64",
+		"arm64":  "/aws/service/debian/release/12/latest/arm64",
+	},
+	"windows-server-2022": {
+		"x86_64": "/aws/service/ami-windows-latest/Windows_Server-2022-English-Full-Base",
+	},
+}
+
+// Image describes an AMI
+type Image struct {
+	ImageID            string             `json:"imageId"`
+	Name               string             `json:"name"`
+	Description        string             `json:"description"`
+	OS                 string             `json:"os,omitempty"` // set for the latest public images
+	Architecture       string             `json:"architecture"`
+	OwnerID            string             `json:"ownerId"`
+	OwnerAlias         string             `json:"ownerAlias"`
+	State              string             `json:"state"`
+	Public             bool               `json:"public"`
+	Platform           string             `json:"platform"`
+	CreationDate       string             `json:"creationDate"`
+	DeprecationTime    string             `json:"deprecationTime,omitempty"`
+	RootDeviceType     string             `json:"rootDeviceType"`
+	VirtualizationType string             `json:"virtualizationType"`
+	BlockDevices       []ImageBlockDevice `json:"blockDevices"`
+	Tags               map[string]string  `json:"tags"`
+}
+
+// ImageBlockDevice is a volume snapshot captured in an AMI
+type ImageBlockDevice struct {
+	DeviceName string `json:"deviceName"`
+	SnapshotID string `json:"snapshotId"`
+	VolumeSize int32  `json:"volumeSize"`
+	VolumeType string `json:"volumeType"`
+	Encrypted  bool   `json:"encrypted"`
+}
+
+// ImageSearch selects AMIs by owner, name pattern and architecture
+type ImageSearch struct {
+	Region       string
+	Owner        string // self (default), amazon, aws-marketplace or an account ID
+	Name         string // wildcard pattern, e.g. "web-*"
+	Architecture string
+}
+
+// CreateImageInput creates an AMI from an instance
+type CreateImageInput struct {
+	Region      string `json:"region"`
+	InstanceID  string `json:"instanceId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	NoReboot    bool   `json:"noReboot"`
+}
+
+// CopyImageInput copies an AMI into another region
+type CopyImageInput struct {
+	SourceRegion      string `json:"sourceRegion"`
+	SourceImageID     string `json:"sourceImageId"`
+	DestinationRegion string `json:"destinationRegion"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	Encrypted         bool   `json:"encrypted"`
+	KmsKeyID          string `json:"kmsKeyId"`
+}
+
+// DeregisterImageResult reports what was removed with an AMI
+type DeregisterImageResult struct {
+	ImageID          string            `json:"imageId"`
+	DeletedSnapshots []string          `json:"deletedSnapshots"`
+	FailedSnapshots  map[string]string `json:"failedSnapshots"`
+}
+
+// SearchImages returns available AMIs matching the search, newest first. At most
+// maxImageSearchPages pages are read, so a broad search returns the newest of those.
+func (s *EC2Service) SearchImages(search ImageSearch) ([]Image, error) {
+	owner := search.Owner
+	if owner == "" {
+		owner = "self"
+	}
+	if owner != "self" && len(strings.NewReplacer("*", "", "?", "").Replace(search.Name)) < minImageNameLength {
+		// Amazon and marketplace catalogues are huge; require a name pattern to narrow them
+		return nil, validationError([]string{fmt.Sprintf("name must have at least %d characters besides wildcards when searching images not owned by this account", minImageNameLength)})
+	}
+
+	input := &ec2.DescribeImagesInput{
+		Owners:     []string{owner},
+		MaxResults: aws.Int32(1000),
+		Filters: []types.Filter{
+			{Name: aws.String("state"), Values: []string{"available"}},
+		},
+	}
+	if search.Name != "" {
+		pattern := search.Name
+		if !strings.ContainsAny(pattern, "*?") {
+			pattern = "*" + pattern + "*"
+		}
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String("name"), Values: []string{pattern}})
+	}
+	if search.Architecture != "" {
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String("architecture"), Values: []string{search.Architecture}})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), imageSearchTimeout)
+	defer cancel()
+
+	client := s.clientForRegion(search.Region)
+	images := []Image{}
+	paginator := ec2.NewDescribeImagesPaginator(client, input)
+	for pages := 0; paginator.HasMorePages() && pages < maxImageSearchPages; pages++ {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe images: %w", err)
+		}
+		for _, image := range page.Images {
+			images = append(images, toImage(image))
+		}
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].CreationDate > images[j].CreationDate
+	})
+	if len(images) > maxImageResults {
+		images = images[:maxImageResults]
+	}
+	return images, nil
+}
+
+// LatestPublicImages resolves the latest Amazon-published AMI for each OS, or only the given one,
+// through the SSM public parameters
+func (s *EC2Service) LatestPublicImages(region, os, architecture string) ([]Image, error) {
+	if architecture == "" {
+		architecture = "x86_64"
+	}
+
+	parameterOS := make(map[string]string)
+	for name, architectures := range publicImageParameters {
+		if os != "" && name != os {
+			continue
+		}
+		if parameter, ok := architectures[architecture]; ok {
+			parameterOS[parameter] = name
+		}
+	}
+	if len(parameterOS) == 0 {
+		return nil, validationError([]string{fmt.Sprintf("no public image for os %q and architecture %q", os, architecture)})
+	}
+
+	ssmClient := s.SSM
+	if region != "" && region != s.SSM.Options().Region {
+		ssmClient = ssm.New(s.SSM.Options(), func(o *ssm.Options) {
+			o.Region = region
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	names := make([]string, 0, len(parameterOS))
+	for parameter := range parameterOS {
+		names = append(names, parameter)
+	}
+	// GetParameters accepts at most 10 names per call
+	imageOS := make(map[string]string)
+	for offset := 0; offset < len(names); offset += 10 {
+		output, err := ssmClient.GetParameters(ctx, &ssm.GetParametersInput{Names: names[offset:min(offset+10, len(names))]})
+		if err != nil {
+			return nil, fmt.Errorf("unable to read public image parameters: %w", err)
+		}
+		for _, parameter := range output.Parameters {
+			imageOS[aws.ToString(parameter.Value)] = parameterOS[aws.ToString(parameter.Name)]
+		}
+	}
+	if len(imageOS) == 0 {
+		return []Image{}, nil
+	}
+
+	imageIDs := make([]string, 0, len(imageOS))
+	for imageID := range imageOS {
+		imageIDs = append(imageIDs, imageID)
+	}
+	output, err := s.clientForRegion(region).DescribeImages(ctx, &ec2.DescribeImagesInput{ImageIds: imageIDs})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe images: %w", err)
+	}
+
+	images := make([]Image, 0, len(output.Images))
+	for _, image := range output.Images {
+		result := toImage(image)
+		result.OS = imageOS[result.ImageID]
+		images = append(images, result)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].OS < images[j].OS
+	})
+	return images, nil
+}
+
+// GetImage returns the details of an AMI
+func (s *EC2Service) GetImage(region, imageID string) (*Image, error) {
+	output, err := s.clientForRegion(region).DescribeImages(context.TODO(), &ec2.DescribeImagesInput{
+		ImageIds: []string{imageID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe image: %w", err)
+	}
+	if len(output.Images) == 0 {
+		return nil, fmt.Errorf("image %s not found", imageID)
+	}
+
+	image := toImage(output.Images[0])
+	return &image, nil
+}
+
+// CreateImage creates an AMI from an instance and returns its ID. The AMI stays pending
+// until its snapshots complete.
+func (s *EC2Service) CreateImage(input CreateImageInput) (string, error) {
+	var problems []string
+	if input.InstanceID == "" {
+		problems = append(problems, "instanceId is required")
+	}
+	if len(input.Name) < 3 || len(input.Name) > 128 {
+		problems = append(problems, "name must be between 3 and 128 characters")
+	}
+	if err := validationError(problems); err != nil {
+		return "", err
+	}
+
+	output, err := s.clientForRegion(input.Region).CreateImage(context.TODO(), &ec2.CreateImageInput{
+		InstanceId:  aws.String(input.InstanceID),
+		Name:        aws.String(input.Name),
+		Description: aws.String(input.Description),
+		NoReboot:    aws.Bool(input.NoReboot),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeImage,
+				Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String(input.Name)}},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to create image: %w", err)
+	}
+	return aws.ToString(output.ImageId), nil
+}
+
+// DeregisterImage deregisters an AMI and, if requested, deletes the snapshots that backed it
+func (s *EC2Service) DeregisterImage(region, imageID string, deleteSnapshots bool) (*DeregisterImageResult, error) {
+	client := s.clientForRegion(region)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Read the snapshot IDs first; they are no longer visible through the image once it is deregistered
+	image, err := s.GetImage(region, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := client.DeregisterImage(ctx, &ec2.DeregisterImageInput{ImageId: aws.String(imageID)}); err != nil {
+		return nil, fmt.Errorf("unable to deregister image: %w", err)
+	}
+
+	result := &DeregisterImageResult{
+		ImageID:          imageID,
+		DeletedSnapshots: []string{},
+		FailedSnapshots:  make(map[string]string),
+	}
+	if !deleteSnapshots {
+		return result, nil
+	}
+
+	for _, device := range image.BlockDevices {
+		if device.SnapshotID == "" {
+			continue
+		}
+		_, err := client.DeleteSnapshot(ctx, &ec2.DeleteSnapshotInput{SnapshotId: aws.String(device.SnapshotID)})
+		if err != nil {
+			result.FailedSnapshots[device.SnapshotID] = apiErrorMessage(err)
+			continue
+		}
+		result.DeletedSnapshots = append(result.DeletedSnapshots, device.SnapshotID)
+	}
+	return result, nil
+}
+
+// CopyImage starts copying an AMI into the destination region and returns the new image ID
+func (s *EC2Service) CopyImage(input CopyImageInput) (string, error) {
+	var problems []string
+	if input.SourceRegion == "" || input.SourceImageID == "" {
+		problems = append(problems, "sourceRegion and sourceImageId are required")
+	}
+	if input.DestinationRegion == "" {
+		problems = append(problems, "destinationRegion is required")
+	}
+	if input.KmsKeyID != "" && !input.Encrypted {
+		problems = append(problems, "kmsKeyId requires encrypted to be true")
+	}
+	if err := validationError(problems); err != nil {
+		return "", err
+	}
+
+	name := input.Name
+	if name == "" {
+		source, err := s.GetImage(input.SourceRegion, input.SourceImageID)
+		if err != nil {
+			return "", err
+		}
+		name = source.Name
+	}
+
+	copyInput := &ec2.CopyImageInput{
+		SourceRegion:  aws.String(input.SourceRegion),
+		SourceImageId: aws.String(input.SourceImageID),
+		Name:          aws.String(name),
+		Description:   aws.String(input.Description),
+		Encrypted:     aws.Bool(input.Encrypted),
+	}
+	if input.KmsKeyID != "" {
+		copyInput.KmsKeyId = aws.String(input.KmsKeyID)
+	}
+
+	// CopyImage is called in the destination region
+	output, err := s.clientForRegion(input.DestinationRegion).CopyImage(context.TODO(), copyInput)
+	if err != nil {
+		return "", fmt.Errorf("unable to copy image: %w", err)
+	}
+	return aws.ToString(output.ImageId), nil
+}
+
+func toImage(image types.Image) Image {
+	result := Image{
+		ImageID:            aws.ToString(image.ImageId),
+		Name:               aws.ToString(image.Name),
+		Description:        aws.ToString(image.Description),
+		Architecture:       string(image.Architecture),
+		OwnerID:            aws.ToString(image.OwnerId),
+		OwnerAlias:         aws.ToString(image.ImageOwnerAlias),
+		State:              string(image.State),
+		Public:             aws.ToBool(image.Public),
+		Platform:           aws.ToString(image.PlatformDetails),
+		CreationDate:       aws.ToString(image.CreationDate),
+		DeprecationTime:    aws.ToString(image.DeprecationTime),
+		RootDeviceType:     string(image.RootDeviceType),
+		VirtualizationType: string(image.VirtualizationType),
+		BlockDevices:       []ImageBlockDevice{},
+		Tags:               tagMap(image.Tags),
+	}
+
+	for _, mapping := range image.BlockDeviceMappings {
+		if mapping.Ebs == nil {
+			continue
+		}
+		result.BlockDevices = append(result.BlockDevices, ImageBlockDevice{
+			DeviceName: aws.ToString(mapping.DeviceName),
+			SnapshotID: aws.ToString(mapping.Ebs.SnapshotId),
+			VolumeSize: aws.ToInt32(mapping.Ebs.VolumeSize),
+			VolumeType: string(mapping.Ebs.VolumeType),
+			Encrypted:  aws.ToBool(mapping.Ebs.Encrypted),
+		})
+	}
+	return result
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// instanceTypeTTL is how long the instance type catalogue of a region is cached
+const instanceTypeTTL = 24 * time.Hour
+
+// InstanceTypeInfo describes an instance type and where it can be launched in a region
+type InstanceTypeInfo struct {
+	InstanceType       string   `json:"instanceType"`
+	Family             string   `json:"family"`
+	VCPUs              int32    `json:"vcpus"`
+	MemoryMiB          int64    `json:"memoryMiB"`
+	MemoryGiB          float64  `json:"memoryGiB"`
+	Architectures      []string `json:"architectures"`
+	NetworkPerformance string   `json:"networkPerformance"`
+	CurrentGeneration  bool     `json:"currentGeneration"`
+	Burstable          bool     `json:"burstable"`
+	FreeTierEligible   bool     `json:"freeTierEligible"`
+	GPUs               int32    `json:"gpus"`
+	AvailabilityZones  []string `json:"availabilityZones"`
+}
+
+// InstanceTypeFilter narrows the catalogue; zero values match everything
+type InstanceTypeFilter struct {
+	Search             string
+	Family             string
+	Architecture       string
+	AvailabilityZone   string
+	NetworkPerformance string
+	MinVCPUs           int32
+	MaxVCPUs           int32
+	MinMemoryGiB       float64
+	MaxMemoryGiB       float64
+	CurrentGeneration  bool
+}
+
+func (f InstanceTypeFilter) matches(info InstanceTypeInfo) bool {
+	switch {
+	case f.Search != "" && !strings.Contains(info.InstanceType, strings.ToLower(f.Search)):
+		return false
+	case f.Family != "" && info.Family != f.Family:
+		return false
+	case f.Architecture != "" && !slices.Contains(info.Architectures, f.Architecture):
+		return false
+	case f.AvailabilityZone != "" && !slices.Contains(info.AvailabilityZones, f.AvailabilityZone):
+		return false
+	case f.NetworkPerformance != "" && !strings.Contains(strings.ToLower(info.NetworkPerformance), strings.ToLower(f.NetworkPerformance)):
+		return false
+	case f.MinVCPUs > 0 && info.VCPUs < f.MinVCPUs:
+		return false
+	case f.MaxVCPUs > 0 && info.VCPUs > f.MaxVCPUs:
+		return false
+	case f.MinMemoryGiB > 0 && info.MemoryGiB < f.MinMemoryGiB:
+		return false
+	case f.MaxMemoryGiB > 0 && info.MemoryGiB > f.MaxMemoryGiB:
+		return false
+	case f.CurrentGeneration && !info.CurrentGeneration:
+		return false
+	}
+	return true
+}
+
+// ListInstanceTypes returns the instance types of a region that match the filter.
+// The catalogue is cached per region; refresh reloads it.
+func (s *EC2Service) ListInstanceTypes(region string, filter InstanceTypeFilter, refresh bool) ([]InstanceTypeInfo, error) {
+	client := s.clientForRegion(region)
+	region = client.Options().Region
+
+	catalogue, ok := s.instanceTypes.Get(region)
+	if !ok || refresh {
+		var err error
+		if catalogue, err = loadInstanceTypes(client); err != nil {
+			return nil, err
+		}
+		s.instanceTypes.Set(region, catalogue)
+	}
+
+	matches := []InstanceTypeInfo{}
+	for _, info := range catalogue {
+		if filter.matches(info) {
+			matches = append(matches, info)
+		}
+	}
+	return matches, nil
+}
+
+func loadInstanceTypes(client *ec2.Client) ([]InstanceTypeInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	zones := make(map[string][]string)
+	offerings := ec2.NewDescribeInstanceTypeOfferingsPaginator(client, &ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: types.LocationTypeAvailabilityZone,
+	})
+	for offerings.HasMorePages() {
+		page, err := offerings.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe instance type offerings: %w", err)
+		}
+		for _, offering := range page.InstanceTypeOfferings {
+			instanceType := string(offering.InstanceType)
+			zones[instanceType] = append(zones[instanceType], aws.ToString(offering.Location))
+		}
+	}
+
+	var catalogue []InstanceTypeInfo
+	paginator := ec2.NewDescribeInstanceTypesPaginator(client, &ec2.DescribeInstanceTypesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe instance types: %w", err)
+		}
+
+		for _, instanceType := range page.InstanceTypes {
+			name := string(instanceType.InstanceType)
+			family, _, _ := strings.Cut(name, ".")
+			info := InstanceTypeInfo{
+				InstanceType:      name,
+				Family:            family,
+				Architectures:     []string{},
+				CurrentGeneration: aws.ToBool(instanceType.CurrentGeneration),
+				Burstable:         aws.ToBool(instanceType.BurstablePerformanceSupported),
+				FreeTierEligible:  aws.ToBool(instanceType.FreeTierEligible),
+				AvailabilityZones: zones[name],
+			}
+			if instanceType.VCpuInfo != nil {
+				info.VCPUs = aws.ToInt32(instanceType.VCpuInfo.DefaultVCpus)
+			}
+			if instanceType.MemoryInfo != nil {
+				info.MemoryMiB = aws.ToInt64(instanceType.MemoryInfo.SizeInMiB)
+				info.MemoryGiB = float64(info.MemoryMiB) / 1024
+			}
+			if instanceType.ProcessorInfo != nil {
+				for _, architecture := range instanceType.ProcessorInfo.SupportedArchitectures {
+					info.Architectures = append(info.Architectures, string(architecture))
+				}
+			}
+			if instanceType.NetworkInfo != nil {
+				info.NetworkPerformance = aws.ToString(instanceType.NetworkInfo.NetworkPerformance)
+			}
+			if instanceType.GpuInfo != nil {
+				for _, gpu := range instanceType.GpuInfo.Gpus {
+					info.GPUs += aws.ToInt32(gpu.Count)
+				}
+			}
+			if info.AvailabilityZones == nil {
+				info.AvailabilityZones = []string{}
+			}
+			sort.Strings(info.AvailabilityZones)
+			catalogue = append(catalogue, info)
+		}
+	}
+
+	sort.Slice(catalogue, func(i, j int) bool {
+		a, b := catalogue[i], catalogue[j]
+		if a.Family != b.Family {
+			return a.Family < b.Family
+		}
+		if a.VCPUs != b.VCPUs {
+			return a.VCPUs < b.VCPUs
+		}
+		if a.MemoryMiB != b.MemoryMiB {
+			return a.MemoryMiB < b.MemoryMiB
+		}
+		return a.InstanceType < b.InstanceType
+	})
+	return catalogue, nil
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// KeyPair describes an EC2 key pair; AWS never returns the private key after creation
+type KeyPair struct {
+	KeyPairID   string            `json:"keyPairId"`
+	KeyName     string            `json:"keyName"`
+	KeyType     string            `json:"keyType"`
+	Fingerprint string            `json:"fingerprint"`
+	PublicKey   string            `json:"publicKey,omitempty"`
+	CreateTime  string            `json:"createTime,omitempty"`
+	Tags        map[string]string `json:"tags"`
+}
+
+// CreatedKeyPair is a new key pair with its private key, which is returned only once
+type CreatedKeyPair struct {
+	KeyPair
+	PrivateKey string `json:"privateKey"`
+	KeyFormat  string `json:"keyFormat"`
+}
+
+// CreateKeyPairInput creates a key pair generated by AWS
+type CreateKeyPairInput struct {
+	Region    string            `json:"region"`
+	KeyName   string            `json:"keyName"`
+	KeyType   string            `json:"keyType"`   // rsa (default) or ed25519
+	KeyFormat string            `json:"keyFormat"` // pem (default) or ppk
+	Tags      map[string]string `json:"tags"`
+}
+
+// Validate checks the input and fills in defaults
+func (input *CreateKeyPairInput) Validate() error {
+	var problems []string
+	problems = append(problems, keyNameProblems(input.KeyName)...)
+	if input.KeyType == "" {
+		input.KeyType = string(types.KeyTypeRsa)
+	}
+	if input.KeyType != string(types.KeyTypeRsa) && input.KeyType != string(types.KeyTypeEd25519) {
+		problems = append(problems, "keyType must be rsa or ed25519")
+	}
+	if input.KeyFormat == "" {
+		input.KeyFormat = string(types.KeyFormatPem)
+	}
+	if input.KeyFormat != string(types.KeyFormatPem) && input.KeyFormat != string(types.KeyFormatPpk) {
+		problems = append(problems, "keyFormat must be pem or ppk")
+	}
+	return validationError(problems)
+}
+
+// ImportKeyPairInput registers an existing public key
+type ImportKeyPairInput struct {
+	Region    string            `json:"region"`
+	KeyName   string            `json:"keyName"`
+	PublicKey string            `json:"publicKey"` // OpenSSH format, e.g. "ssh-ed25519 AAAA..."
+	Tags      map[string]string `json:"tags"`
+}
+
+// Validate checks the input
+func (input *ImportKeyPairInput) Validate() error {
+	var problems []string
+	problems = append(problems, keyNameProblems(input.KeyName)...)
+	input.PublicKey = strings.TrimSpace(input.PublicKey)
+	switch {
+	case input.PublicKey == "":
+		problems = append(problems, "publicKey is required")
+	case strings.Contains(input.PublicKey, "PRIVATE KEY"):
+		problems = append(problems, "publicKey contains a private key; only the public key must be sent")
+	case !strings.HasPrefix(input.PublicKey, "ssh-rsa ") && !strings.HasPrefix(input.PublicKey, "ssh-ed25519 ") &&
+		!strings.HasPrefix(input.PublicKey, "---- BEGIN SSH2 PUBLIC KEY ----"):
+		problems = append(problems, "publicKey must be an ssh-rsa or ssh-ed25519 public key")
+	}
+	return validationError(problems)
+}
+
+func keyNameProblems(keyName string) []string {
+	if keyName == "" || len(keyName) > 255 {
+		return []string{"keyName must be between 1 and 255 characters"}
+	}
+	return nil
+}
+
+// ListKeyPairs returns the key pairs of a region sorted by name
+func (s *EC2Service) ListKeyPairs(region string) ([]KeyPair, error) {
+	output, err := s.clientForRegion(region).DescribeKeyPairs(context.TODO(), &ec2.DescribeKeyPairsInput{
+		IncludePublicKey: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe key pairs: %w", err)
+	}
+
+	keyPairs := make([]KeyPair, 0, len(output.KeyPairs))
+	for _, keyPair := range output.KeyPairs {
+		keyPairs = append(keyPairs, KeyPair{
+			KeyPairID:   aws.ToString(keyPair.KeyPairId),
+			KeyName:     aws.ToString(keyPair.KeyName),
+			KeyType:     string(keyPair.KeyType),
+			Fingerprint: aws.ToString(keyPair.KeyFingerprint),
+			PublicKey:   strings.TrimSpace(aws.ToString(keyPair.PublicKey)),
+			CreateTime:  formatTime(keyPair.CreateTime),
+			Tags:        tagMap(keyPair.Tags),
+		})
+	}
+	sort.Slice(keyPairs, func(i, j int) bool {
+		return keyPairs[i].KeyName < keyPairs[j].KeyName
+	})
+	return keyPairs, nil
+}
+
+// CreateKeyPair creates a key pair and returns its private key. AWS keeps no copy of the
+// private key, so it cannot be retrieved again.
+func (s *EC2Service) CreateKeyPair(input CreateKeyPairInput) (*CreatedKeyPair, error) {
+	output, err := s.clientForRegion(input.Region).CreateKeyPair(context.TODO(), &ec2.CreateKeyPairInput{
+		KeyName:           aws.String(input.KeyName),
+		KeyType:           types.KeyType(input.KeyType),
+		KeyFormat:         types.KeyFormat(input.KeyFormat),
+		TagSpecifications: keyPairTags(input.Tags),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create key pair: %w", err)
+	}
+
+	return &CreatedKeyPair{
+		KeyPair: KeyPair{
+			KeyPairID:   aws.ToString(output.KeyPairId),
+			KeyName:     aws.ToString(output.KeyName),
+			KeyType:     input.KeyType,
+			Fingerprint: aws.ToString(output.KeyFingerprint),
+			Tags:        tagMap(output.Tags),
+		},
+		PrivateKey: aws.ToString(output.KeyMaterial),
+		KeyFormat:  input.KeyFormat,
+	}, nil
+}
+
+// ImportKeyPair registers an existing public key as a key pair
+func (s *EC2Service) ImportKeyPair(input ImportKeyPairInput) (*KeyPair, error) {
+	output, err := s.clientForRegion(input.Region).ImportKeyPair(context.TODO(), &ec2.ImportKeyPairInput{
+		KeyName:           aws.String(input.KeyName),
+		PublicKeyMaterial: []byte(input.PublicKey),
+		TagSpecifications: keyPairTags(input.Tags),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to import key pair: %w", err)
+	}
+
+	keyType := string(types.KeyTypeRsa)
+	if strings.HasPrefix(input.PublicKey, "ssh-ed25519 ") {
+		keyType = string(types.KeyTypeEd25519)
+	}
+	return &KeyPair{
+		KeyPairID:   aws.ToString(output.KeyPairId),
+		KeyName:     aws.ToString(output.KeyName),
+		KeyType:     keyType,
+		Fingerprint: aws.ToString(output.KeyFingerprint),
+		PublicKey:   input.PublicKey,
+		Tags:        tagMap(output.Tags),
+	}, nil
+}
+
+// DeleteKeyPair deletes a key pair by name or, when keyPairID is set, by ID.
+// Instances launched with it keep the public key.
+func (s *EC2Service) DeleteKeyPair(region, keyName, keyPairID string) error {
+	input := &ec2.DeleteKeyPairInput{}
+	if keyPairID != "" {
+		input.KeyPairId = aws.String(keyPairID)
+	} else {
+		input.KeyName = aws.String(keyName)
+	}
+
+	if _, err := s.clientForRegion(region).DeleteKeyPair(context.TODO(), input); err != nil {
+		return fmt.Errorf("unable to delete key pair: %w", err)
+	}
+	return nil
+}
+
+func keyPairTags(tags map[string]string) []types.TagSpecification {
+	if len(tags) == 0 {
+		return nil
+	}
+	result := make([]types.Tag, 0, len(tags))
+	for _, key := range sortedKeys(tags) {
+		result = append(result, types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return []types.TagSpecification{{ResourceType: types.ResourceTypeKeyPair, Tags: result}}
+}
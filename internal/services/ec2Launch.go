@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const (
+	// maxUserDataBytes is the EC2 limit on user data before base64 encoding
+	maxUserDataBytes = 16 * 1024
+	maxLaunchTags    = 50
+)
+
+var ebsVolumeTypes = map[string]bool{"gp2": true, "gp3": true, "io1": true, "io2": true, "st1": true, "sc1": true, "standard": true}
+
+type LaunchInstanceInput struct {
+	AMI            string   `json:"ami"`
+	InstanceType   string   `json:"instanceType"`
+	KeyPair        string   `json:"keyPair"`
+	SecurityGroups []string `json:"securityGroups"`
+	InstanceName   string   `json:"instanceName"`
+	MinCount       int32    `json:"minCount"`
+	MaxCount       int32    `json:"maxCount"`
+	Region         string   `json:"region"`
+
+	SubnetID           string            `json:"subnetId"`
+	AssociatePublicIP  *bool             `json:"associatePublicIp"`  // nil keeps the subnet default
+	UserData           string            `json:"userData"`           // plain text, encoded before sending
+	IamInstanceProfile string            `json:"iamInstanceProfile"` // profile name or ARN
+	BlockDevices       []BlockDevice     `json:"blockDevices"`
+	Tags               map[string]string `json:"tags"`
+	Monitoring         bool              `json:"monitoring"`
+	RequireIMDSv2      bool              `json:"requireImdsv2"`
+	MetadataHopLimit   int32             `json:"metadataHopLimit"`
+	Spot               *SpotOptions      `json:"spot"` // nil launches on-demand
+}
+
+// BlockDevice is an EBS volume attached at launch
+type BlockDevice struct {
+	DeviceName          string `json:"deviceName"`
+	VolumeSize          int32  `json:"volumeSize"` // GiB
+	VolumeType          string `json:"volumeType"`
+	Iops                int32  `json:"iops"`
+	Throughput          int32  `json:"throughput"` // MiB/s, gp3 only
+	Encrypted           bool   `json:"encrypted"`
+	KmsKeyID            string `json:"kmsKeyId"`
+	SnapshotID          string `json:"snapshotId"`
+	DeleteOnTermination *bool  `json:"deleteOnTermination"` // defaults to true
+}
+
+// Validate checks the input and fills in defaults
+func (input *LaunchInstanceInput) Validate() error {
+	var problems []string
+	if input.AMI == "" {
+		problems = append(problems, "ami is required")
+	}
+	if input.InstanceType == "" {
+		problems = append(problems, "instanceType is required")
+	}
+
+	if input.MinCount == 0 {
+		input.MinCount = 1
+	}
+	if input.MaxCount == 0 {
+		input.MaxCount = input.MinCount
+	}
+	if input.MinCount < 1 {
+		problems = append(problems, "minCount must be at least 1")
+	}
+	if input.MaxCount < input.MinCount {
+		problems = append(problems, fmt.Sprintf("minCount (%d) must not be greater than maxCount (%d)", input.MinCount, input.MaxCount))
+	}
+
+	if len(input.UserData) > maxUserDataBytes {
+		problems = append(problems, fmt.Sprintf("userData must be at most %d bytes", maxUserDataBytes))
+	}
+	if input.MetadataHopLimit != 0 && (input.MetadataHopLimit < 1 || input.MetadataHopLimit > 64) {
+		problems = append(problems, "metadataHopLimit must be between 1 and 64")
+	}
+
+	if input.Spot != nil {
+		problems = append(problems, input.Spot.validate()...)
+	}
+
+	devices := make(map[string]bool)
+	for i, device := range input.BlockDevices {
+		problems = append(problems, device.validate(i)...)
+		if devices[device.DeviceName] {
+			problems = append(problems, fmt.Sprintf("blockDevices[%d]: device %s is mapped more than once", i, device.DeviceName))
+		}
+		devices[device.DeviceName] = true
+	}
+
+	if len(input.Tags) > maxLaunchTags {
+		problems = append(problems, fmt.Sprintf("at most %d tags are allowed", maxLaunchTags))
+	}
+	for _, key := range sortedKeys(input.Tags) {
+		value := input.Tags[key]
+		switch {
+		case key == "" || len(key) > 128:
+			problems = append(problems, fmt.Sprintf("tag key %q must be between 1 and 128 characters", key))
+		case strings.HasPrefix(strings.ToLower(key), "aws:"):
+			problems = append(problems, fmt.Sprintf("tag key %q uses the reserved aws: prefix", key))
+		case key == "Name" && input.InstanceName != "":
+			problems = append(problems, "set the Name tag through instanceName only")
+		}
+		if len(value) > 256 {
+			problems = append(problems, fmt.Sprintf("tag %q value must be at most 256 characters", key))
+		}
+	}
+
+	return validationError(problems)
+}
+
+func (d BlockDevice) validate(index int) []string {
+	var problems []string
+	field := fmt.Sprintf("blockDevices[%d]", index)
+
+	if d.DeviceName == "" {
+		problems = append(problems, field+": deviceName is required")
+	}
+	if d.VolumeSize == 0 && d.SnapshotID == "" {
+		problems = append(problems, field+": volumeSize is required unless a snapshotId is given")
+	}
+	if d.VolumeSize < 0 || d.VolumeSize > 65536 {
+		problems = append(problems, field+": volumeSize must be between 1 and 65536 GiB")
+	}
+
+	problems = append(problems, volumeSpecProblems(field, d.VolumeType, d.Iops, d.Throughput)...)
+
+	if d.KmsKeyID != "" && !d.Encrypted {
+		problems = append(problems, field+": kmsKeyId requires encrypted to be true")
+	}
+	return problems
+}
+
+// volumeSpecProblems checks the volume type and the IOPS and throughput it allows; an empty type means gp3
+func volumeSpecProblems(field, volumeType string, iops, throughput int32) []string {
+	var problems []string
+	if volumeType == "" {
+		volumeType = "gp3"
+	}
+	if !ebsVolumeTypes[volumeType] {
+		problems = append(problems, fmt.Sprintf("%s: unsupported volumeType %q", field, volumeType))
+	}
+
+	switch volumeType {
+	case "io1", "io2":
+		if iops <= 0 {
+			problems = append(problems, fmt.Sprintf("%s: iops is required for %s volumes", field, volumeType))
+		}
+	case "gp3":
+		if iops != 0 && (iops < 3000 || iops > 16000) {
+			problems = append(problems, field+": gp3 iops must be between 3000 and 16000")
+		}
+	default:
+		if iops != 0 {
+			problems = append(problems, fmt.Sprintf("%s: iops cannot be set for %s volumes", field, volumeType))
+		}
+	}
+
+	if throughput != 0 {
+		if volumeType != "gp3" {
+			problems = append(problems, field+": throughput can only be set for gp3 volumes")
+		} else if throughput < 125 || throughput > 1000 {
+			problems = append(problems, field+": throughput must be between 125 and 1000 MiB/s")
+		}
+	}
+	return problems
+}
+
+// runInstancesInput builds the RunInstances request for a validated input
+func (input LaunchInstanceInput) runInstancesInput() *ec2.RunInstancesInput {
+	runInput := &ec2.RunInstancesInput{
+		ImageId:      aws.String(input.AMI),
+		InstanceType: types.InstanceType(input.InstanceType),
+		MinCount:     aws.Int32(input.MinCount),
+		MaxCount:     aws.Int32(input.MaxCount),
+		Monitoring:   &types.RunInstancesMonitoringEnabled{Enabled: aws.Bool(input.Monitoring)},
+	}
+	if input.KeyPair != "" {
+		runInput.KeyName = aws.String(input.KeyPair)
+	}
+
+	// Public IP association can only be requested on a network interface, which then
+	// has to carry the subnet and security groups as well
+	if input.AssociatePublicIP != nil {
+		networkInterface := types.InstanceNetworkInterfaceSpecification{
+			DeviceIndex:              aws.Int32(0),
+			AssociatePublicIpAddress: input.AssociatePublicIP,
+			Groups:                   input.SecurityGroups,
+			DeleteOnTermination:      aws.Bool(true),
+		}
+		if input.SubnetID != "" {
+			networkInterface.SubnetId = aws.String(input.SubnetID)
+		}
+		runInput.NetworkInterfaces = []types.InstanceNetworkInterfaceSpecification{networkInterface}
+	} else {
+		runInput.SecurityGroupIds = input.SecurityGroups
+		if input.SubnetID != "" {
+			runInput.SubnetId = aws.String(input.SubnetID)
+		}
+	}
+
+	if input.Spot != nil {
+		runInput.InstanceMarketOptions = input.Spot.marketOptions()
+	}
+
+	if input.UserData != "" {
+		runInput.UserData = aws.String(base64.StdEncoding.EncodeToString([]byte(input.UserData)))
+	}
+
+	if input.IamInstanceProfile != "" {
+		if strings.HasPrefix(input.IamInstanceProfile, "arn:") {
+			runInput.IamInstanceProfile = &types.IamInstanceProfileSpecification{Arn: aws.String(input.IamInstanceProfile)}
+		} else {
+			runInput.IamInstanceProfile = &types.IamInstanceProfileSpecification{Name: aws.String(input.IamInstanceProfile)}
+		}
+	}
+
+	for _, device := range input.BlockDevices {
+		ebs := &types.EbsBlockDevice{
+			VolumeType:          types.VolumeType(device.VolumeType),
+			DeleteOnTermination: aws.Bool(device.DeleteOnTermination == nil || *device.DeleteOnTermination),
+		}
+		if device.VolumeType == "" {
+			ebs.VolumeType = types.VolumeTypeGp3
+		}
+		if device.VolumeSize > 0 {
+			ebs.VolumeSize = aws.Int32(device.VolumeSize)
+		}
+		if device.Iops > 0 {
+			ebs.Iops = aws.Int32(device.Iops)
+		}
+		if device.Throughput > 0 {
+			ebs.Throughput = aws.Int32(device.Throughput)
+		}
+		if device.SnapshotID != "" {
+			ebs.SnapshotId = aws.String(device.SnapshotID)
+		}
+		// Leave encryption unset unless requested so the account's default encryption applies
+		if device.Encrypted {
+			ebs.Encrypted = aws.Bool(true)
+			if device.KmsKeyID != "" {
+				ebs.KmsKeyId = aws.String(device.KmsKeyID)
+			}
+		}
+		runInput.BlockDeviceMappings = append(runInput.BlockDeviceMappings, types.BlockDeviceMapping{
+			DeviceName: aws.String(device.DeviceName),
+			Ebs:        ebs,
+		})
+	}
+
+	if input.RequireIMDSv2 || input.MetadataHopLimit != 0 {
+		options := &types.InstanceMetadataOptionsRequest{HttpEndpoint: types.InstanceMetadataEndpointStateEnabled}
+		if input.RequireIMDSv2 {
+			options.HttpTokens = types.HttpTokensStateRequired
+		}
+		if input.MetadataHopLimit != 0 {
+			options.HttpPutResponseHopLimit = aws.Int32(input.MetadataHopLimit)
+		}
+		runInput.MetadataOptions = options
+	}
+
+	tags := make([]types.Tag, 0, len(input.Tags)+1)
+	if input.InstanceName != "" {
+		tags = append(tags, types.Tag{Key: aws.String("Name"), Value: aws.String(input.InstanceName)})
+	}
+	for _, key := range sortedKeys(input.Tags) {
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(input.Tags[key])})
+	}
+	if len(tags) > 0 {
+		runInput.TagSpecifications = []types.TagSpecification{
+			{ResourceType: types.ResourceTypeInstance, Tags: tags},
+			{ResourceType: types.ResourceTypeVolume, Tags: tags},
+		}
+	}
+
+	return runInput
+}
+
+// LaunchInstance launches instances in the requested region and returns all of their IDs.
+// Call Validate on the input first.
+func (s *EC2Service) LaunchInstance(input LaunchInstanceInput) ([]string, error) {
+	client := s.clientForRegion(input.Region)
+
+	// Run the instances
+	output, err := client.RunInstances(context.TODO(), input.runInstancesInput())
+	if err != nil {
+		return nil, fmt.Errorf("failed to launch instance: %w", err)
+	}
+
+	instanceIDs := make([]string, 0, len(output.Instances))
+	for _, instance := range output.Instances {
+		instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+	}
+	if len(instanceIDs) == 0 {
+		return nil, fmt.Errorf("no instances were launched")
+	}
+
+	return instanceIDs, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// LaunchTemplate summarizes an EC2 launch template
+type LaunchTemplate struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	DefaultVersion int64             `json:"defaultVersion"`
+	LatestVersion  int64             `json:"latestVersion"`
+	CreatedAt      string            `json:"createdAt"`
+	CreatedBy      string            `json:"createdBy"`
+	Tags           map[string]string `json:"tags"`
+}
+
+// LaunchTemplateVersion is one version of a launch template with the settings it defines
+type LaunchTemplateVersion struct {
+	TemplateID         string            `json:"templateId"`
+	TemplateName       string            `json:"templateName"`
+	Version            int64             `json:"version"`
+	Description        string            `json:"description"`
+	IsDefault          bool              `json:"isDefault"`
+	CreatedAt          string            `json:"createdAt"`
+	AMI                string            `json:"ami"`
+	InstanceType       string            `json:"instanceType"`
+	KeyPair            string            `json:"keyPair"`
+	SecurityGroups     []string          `json:"securityGroups"`
+	IamInstanceProfile string            `json:"iamInstanceProfile"`
+	Tags               map[string]string `json:"tags"`
+}
+
+// LaunchTemplateOverrides replaces settings of the template for a single launch
+type LaunchTemplateOverrides struct {
+	AMI            string   `json:"ami"`
+	InstanceType   string   `json:"instanceType"`
+	KeyPair        string   `json:"keyPair"`
+	SecurityGroups []string `json:"securityGroups"`
+	SubnetID       string   `json:"subnetId"`
+}
+
+// LaunchFromTemplateInput describes a batch launch from a launch template
+type LaunchFromTemplateInput struct {
+	Region       string                  `json:"region"`
+	TemplateID   string                  `json:"templateId"`
+	TemplateName string                  `json:"templateName"`
+	Version      string                  `json:"version"` // a number, $Latest or $Default (the default)
+	InstanceName string                  `json:"instanceName"`
+	MinCount     int32                   `json:"minCount"`
+	MaxCount     int32                   `json:"maxCount"`
+	Overrides    LaunchTemplateOverrides `json:"overrides"`
+}
+
+// Validate checks the input and fills in defaults
+func (input *LaunchFromTemplateInput) Validate() error {
+	var problems []string
+	if (input.TemplateID == "") == (input.TemplateName == "") {
+		problems = append(problems, "exactly one of templateId or templateName is required")
+	}
+
+	if input.Version == "" {
+		input.Version = "$Default"
+	}
+	if input.Version != "$Default" && input.Version != "$Latest" {
+		if version, err := strconv.ParseInt(input.Version, 10, 64); err != nil || version < 1 {
+			problems = append(problems, "version must be a positive number, $Latest or $Default")
+		}
+	}
+
+	if input.MinCount == 0 {
+		input.MinCount = 1
+	}
+	if input.MaxCount == 0 {
+		input.MaxCount = input.MinCount
+	}
+	if input.MinCount < 1 || input.MaxCount < input.MinCount {
+		problems = append(problems, "minCount must be at least 1 and no greater than maxCount")
+	}
+
+	return validationError(problems)
+}
+
+// CreateTemplateFromInstanceInput names the launch template captured from an instance
+type CreateTemplateFromInstanceInput struct {
+	Region       string `json:"region"` // region of the instance; the template is created there too
+	InstanceID   string `json:"instanceId"`
+	TemplateName string `json:"templateName"`
+	Description  string `json:"description"`
+}
+
+// ListLaunchTemplates returns the launch templates in a region, sorted by name
+func (s *EC2Service) ListLaunchTemplates(region string) ([]LaunchTemplate, error) {
+	client := s.clientForRegion(region)
+
+	templates := []LaunchTemplate{}
+	paginator := ec2.NewDescribeLaunchTemplatesPaginator(client, &ec2.DescribeLaunchTemplatesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe launch templates: %w", err)
+		}
+
+		for _, template := range page.LaunchTemplates {
+			templates = append(templates, LaunchTemplate{
+				ID:             aws.ToString(template.LaunchTemplateId),
+				Name:           aws.ToString(template.LaunchTemplateName),
+				DefaultVersion: aws.ToInt64(template.DefaultVersionNumber),
+				LatestVersion:  aws.ToInt64(template.LatestVersionNumber),
+				CreatedAt:      formatTime(template.CreateTime),
+				CreatedBy:      aws.ToString(template.CreatedBy),
+				Tags:           tagMap(template.Tags),
+			})
+		}
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+// ListLaunchTemplateVersions returns every version of a launch template, newest first
+func (s *EC2Service) ListLaunchTemplateVersions(region, templateID string) ([]LaunchTemplateVersion, error) {
+	client := s.clientForRegion(region)
+
+	versions := []LaunchTemplateVersion{}
+	paginator := ec2.NewDescribeLaunchTemplateVersionsPaginator(client, &ec2.DescribeLaunchTemplateVersionsInput{
+		LaunchTemplateId: aws.String(templateID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe launch template versions: %w", err)
+		}
+
+		for _, version := range page.LaunchTemplateVersions {
+			versions = append(versions, toLaunchTemplateVersion(version))
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+	return versions, nil
+}
+
+func toLaunchTemplateVersion(version types.LaunchTemplateVersion) LaunchTemplateVersion {
+	result := LaunchTemplateVersion{
+		TemplateID:     aws.ToString(version.LaunchTemplateId),
+		TemplateName:   aws.ToString(version.LaunchTemplateName),
+		Version:        aws.ToInt64(version.VersionNumber),
+		Description:    aws.ToString(version.VersionDescription),
+		IsDefault:      aws.ToBool(version.DefaultVersion),
+		CreatedAt:      formatTime(version.CreateTime),
+		SecurityGroups: []string{},
+		Tags:           make(map[string]string),
+	}
+
+	data := version.LaunchTemplateData
+	if data == nil {
+		return result
+	}
+
+	result.AMI = aws.ToString(data.ImageId)
+	result.InstanceType = string(data.InstanceType)
+	result.KeyPair = aws.ToString(data.KeyName)
+	result.SecurityGroups = append(result.SecurityGroups, data.SecurityGroupIds...)
+	// Templates created with network interfaces carry their security groups there instead
+	for _, networkInterface := range data.NetworkInterfaces {
+		result.SecurityGroups = append(result.SecurityGroups, networkInterface.Groups...)
+	}
+	if data.IamInstanceProfile != nil {
+		result.IamInstanceProfile = aws.ToString(data.IamInstanceProfile.Arn)
+		if result.IamInstanceProfile == "" {
+			result.IamInstanceProfile = aws.ToString(data.IamInstanceProfile.Name)
+		}
+	}
+	for _, spec := range data.TagSpecifications {
+		if spec.ResourceType == types.ResourceTypeInstance {
+			for _, tag := range spec.Tags {
+				result.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+		}
+	}
+
+	return result
+}
+
+// LaunchFromTemplate launches instances from a launch template version, applying any overrides.
+// Call Validate on the input first.
+func (s *EC2Service) LaunchFromTemplate(input LaunchFromTemplateInput) ([]string, error) {
+	client := s.clientForRegion(input.Region)
+
+	runInput := &ec2.RunInstancesInput{
+		LaunchTemplate: &types.LaunchTemplateSpecification{
+			Version: aws.String(input.Version),
+		},
+		MinCount: aws.Int32(input.MinCount),
+		MaxCount: aws.Int32(input.MaxCount),
+	}
+	if input.TemplateID != "" {
+		runInput.LaunchTemplate.LaunchTemplateId = aws.String(input.TemplateID)
+	} else {
+		runInput.LaunchTemplate.LaunchTemplateName = aws.String(input.TemplateName)
+	}
+
+	overrides := input.Overrides
+	if overrides.AMI != "" {
+		runInput.ImageId = aws.String(overrides.AMI)
+	}
+	if overrides.InstanceType != "" {
+		runInput.InstanceType = types.InstanceType(overrides.InstanceType)
+	}
+	if overrides.KeyPair != "" {
+		runInput.KeyName = aws.String(overrides.KeyPair)
+	}
+	if len(overrides.SecurityGroups) > 0 {
+		runInput.SecurityGroupIds = overrides.SecurityGroups
+	}
+	if overrides.SubnetID != "" {
+		runInput.SubnetId = aws.String(overrides.SubnetID)
+	}
+	if input.InstanceName != "" {
+		// Tag specifications given at launch are merged with those of the template
+		runInput.TagSpecifications = []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeInstance,
+				Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String(input.InstanceName)}},
+			},
+		}
+	}
+
+	output, err := client.RunInstances(context.TODO(), runInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to launch instances from template: %w", err)
+	}
+
+	instanceIDs := make([]string, 0, len(output.Instances))
+	for _, instance := range output.Instances {
+		instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+	}
+	if len(instanceIDs) == 0 {
+		return nil, fmt.Errorf("no instances were launched")
+	}
+	return instanceIDs, nil
+}
+
+// CreateTemplateFromInstance captures the configuration of an existing instance as a new launch template
+func (s *EC2Service) CreateTemplateFromInstance(input CreateTemplateFromInstanceInput) (*LaunchTemplate, error) {
+	var problems []string
+	if input.InstanceID == "" {
+		problems = append(problems, "instanceId is required")
+	}
+	if input.TemplateName == "" {
+		problems = append(problems, "templateName is required")
+	}
+	if err := validationError(problems); err != nil {
+		return nil, err
+	}
+
+	client := s.clientForRegion(input.Region)
+	detail, err := s.instanceDetails(client, input.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &types.RequestLaunchTemplateData{
+		ImageId:          aws.String(detail.ImageID),
+		InstanceType:     types.InstanceType(detail.InstanceType),
+		SecurityGroupIds: detail.SecurityGroupIDs,
+		EbsOptimized:     aws.Bool(detail.EbsOptimized),
+		Monitoring:       &types.LaunchTemplatesMonitoringRequest{Enabled: aws.Bool(detail.Monitoring)},
+	}
+	if detail.KeyName != "" {
+		data.KeyName = aws.String(detail.KeyName)
+	}
+	if detail.IamInstanceProfile != "" {
+		data.IamInstanceProfile = &types.LaunchTemplateIamInstanceProfileSpecificationRequest{
+			Arn: aws.String(detail.IamInstanceProfile),
+		}
+	}
+
+	// Copy user tags; the aws: prefix is reserved and cannot be set
+	var tags []types.Tag
+	for key, value := range detail.TagMap {
+		if !strings.HasPrefix(key, "aws:") {
+			tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+	}
+	if len(tags) > 0 {
+		sort.Slice(tags, func(i, j int) bool {
+			return aws.ToString(tags[i].Key) < aws.ToString(tags[j].Key)
+		})
+		data.TagSpecifications = []types.LaunchTemplateTagSpecificationRequest{
+			{ResourceType: types.ResourceTypeInstance, Tags: tags},
+		}
+	}
+
+	description := input.Description
+	if description == "" {
+		description = fmt.Sprintf("Created from instance %s", detail.ID)
+	}
+
+	output, err := client.CreateLaunchTemplate(context.TODO(), &ec2.CreateLaunchTemplateInput{
+		LaunchTemplateName: aws.String(input.TemplateName),
+		VersionDescription: aws.String(description),
+		LaunchTemplateData: data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create launch template: %w", err)
+	}
+
+	template := output.LaunchTemplate
+	return &LaunchTemplate{
+		ID:             aws.ToString(template.LaunchTemplateId),
+		Name:           aws.ToString(template.LaunchTemplateName),
+		DefaultVersion: aws.ToInt64(template.DefaultVersionNumber),
+		LatestVersion:  aws.ToInt64(template.LatestVersionNumber),
+		CreatedAt:      formatTime(template.CreateTime),
+		CreatedBy:      aws.ToString(template.CreatedBy),
+		Tags:           tagMap(template.Tags),
+	}, nil
+}
+
+// tagMap converts EC2 tags into a map
+func tagMap(tags []types.Tag) map[string]string {
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return result
+}
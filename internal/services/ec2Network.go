@@ -0,0 +1,639 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// reservedSubnetIPs is the number of addresses AWS reserves in every subnet
+const reservedSubnetIPs = 5
+
+// NetworkService exposes VPC networking resources and how instances are placed in them
+type NetworkService struct {
+	EC2 *EC2Service
+}
+
+// Vpc describes a VPC
+type Vpc struct {
+	VpcID           string            `json:"vpcId"`
+	Name            string            `json:"name"`
+	CidrBlocks      []string          `json:"cidrBlocks"`
+	Ipv6CidrBlocks  []string          `json:"ipv6CidrBlocks"`
+	State           string            `json:"state"`
+	IsDefault       bool              `json:"isDefault"`
+	InstanceTenancy string            `json:"instanceTenancy"`
+	DhcpOptionsID   string            `json:"dhcpOptionsId"`
+	Tags            map[string]string `json:"tags"`
+}
+
+// Subnet describes a subnet and how many addresses are still free
+type Subnet struct {
+	SubnetID            string            `json:"subnetId"`
+	Name                string            `json:"name"`
+	VpcID               string            `json:"vpcId"`
+	CidrBlock           string            `json:"cidrBlock"`
+	Ipv6CidrBlocks      []string          `json:"ipv6CidrBlocks"`
+	AvailabilityZone    string            `json:"availabilityZone"`
+	State               string            `json:"state"`
+	TotalIPCount        int64             `json:"totalIpCount"` // usable addresses, excluding the five AWS reserves
+	AvailableIPCount    int32             `json:"availableIpCount"`
+	UsedIPCount         int64             `json:"usedIpCount"`
+	MapPublicIPOnLaunch bool              `json:"mapPublicIpOnLaunch"`
+	DefaultForAz        bool              `json:"defaultForAz"`
+	RouteTableID        string            `json:"routeTableId"`
+	Public              bool              `json:"public"` // routes to an internet gateway
+	Tags                map[string]string `json:"tags"`
+}
+
+// RouteTable describes a route table and the subnets using it
+type RouteTable struct {
+	RouteTableID string            `json:"routeTableId"`
+	Name         string            `json:"name"`
+	VpcID        string            `json:"vpcId"`
+	Main         bool              `json:"main"`
+	SubnetIDs    []string          `json:"subnetIds"`
+	Routes       []Route           `json:"routes"`
+	Tags         map[string]string `json:"tags"`
+}
+
+// Route is a single route table entry
+type Route struct {
+	Destination string `json:"destination"`
+	Target      string `json:"target"`
+	TargetType  string `json:"targetType"`
+	State       string `json:"state"`
+}
+
+// InternetGateway describes an internet gateway and the VPCs it is attached to
+type InternetGateway struct {
+	InternetGatewayID string            `json:"internetGatewayId"`
+	Name              string            `json:"name"`
+	VpcIDs            []string          `json:"vpcIds"`
+	Tags              map[string]string `json:"tags"`
+}
+
+// NatGateway describes a NAT gateway
+type NatGateway struct {
+	NatGatewayID     string            `json:"natGatewayId"`
+	Name             string            `json:"name"`
+	VpcID            string            `json:"vpcId"`
+	SubnetID         string            `json:"subnetId"`
+	State            string            `json:"state"`
+	ConnectivityType string            `json:"connectivityType"` // public or private
+	PublicIP         string            `json:"publicIp"`
+	PrivateIP        string            `json:"privateIp"`
+	CreateTime       string            `json:"createTime"`
+	Tags             map[string]string `json:"tags"`
+}
+
+// NetworkInterface describes an elastic network interface
+type NetworkInterface struct {
+	NetworkInterfaceID string   `json:"networkInterfaceId"`
+	Description        string   `json:"description"`
+	InterfaceType      string   `json:"interfaceType"`
+	Status             string   `json:"status"`
+	VpcID              string   `json:"vpcId"`
+	SubnetID           string   `json:"subnetId"`
+	AvailabilityZone   string   `json:"availabilityZone"`
+	PrivateIP          string   `json:"privateIp"`
+	PrivateIPs         []string `json:"privateIps"`
+	PublicIP           string   `json:"publicIp"`
+	MacAddress         string   `json:"macAddress"`
+	InstanceID         string   `json:"instanceId"`
+	SecurityGroupIDs   []string `json:"securityGroupIds"`
+	RequesterManaged   bool     `json:"requesterManaged"` // created by an AWS service such as a load balancer
+}
+
+// TopologyGraph is the VPC → subnet → instance graph of a region
+type TopologyGraph struct {
+	Region      string         `json:"region"`
+	GeneratedAt string         `json:"generatedAt"`
+	Nodes       []TopologyNode `json:"nodes"`
+	Edges       []TopologyEdge `json:"edges"`
+}
+
+// TopologyNode is a VPC, subnet, instance or gateway in the topology graph
+type TopologyNode struct {
+	ID    string            `json:"id"`
+	Type  string            `json:"type"` // vpc, subnet, instance, internet-gateway or nat-gateway
+	Label string            `json:"label"`
+	Data  map[string]string `json:"data"`
+}
+
+// TopologyEdge connects two nodes of the topology graph
+type TopologyEdge struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Relation string `json:"relation"` // contains or attached
+}
+
+// vpcFilter returns a filter on the given field, or nil when vpcID is empty
+func vpcFilter(field, vpcID string) []types.Filter {
+	if vpcID == "" {
+		return nil
+	}
+	return []types.Filter{{Name: aws.String(field), Values: []string{vpcID}}}
+}
+
+// ListVpcs returns the VPCs of a region
+func (s *NetworkService) ListVpcs(region string) ([]Vpc, error) {
+	return describeVpcs(context.TODO(), s.EC2.clientForRegion(region), "")
+}
+
+func describeVpcs(ctx context.Context, client *ec2.Client, vpcID string) ([]Vpc, error) {
+	vpcs := []Vpc{}
+	paginator := ec2.NewDescribeVpcsPaginator(client, &ec2.DescribeVpcsInput{Filters: vpcFilter("vpc-id", vpcID)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe VPCs: %w", err)
+		}
+		for _, vpc := range page.Vpcs {
+			result := Vpc{
+				VpcID:           aws.ToString(vpc.VpcId),
+				CidrBlocks:      []string{},
+				Ipv6CidrBlocks:  []string{},
+				State:           string(vpc.State),
+				IsDefault:       aws.ToBool(vpc.IsDefault),
+				InstanceTenancy: string(vpc.InstanceTenancy),
+				DhcpOptionsID:   aws.ToString(vpc.DhcpOptionsId),
+				Tags:            tagMap(vpc.Tags),
+			}
+			result.Name = result.Tags["Name"]
+			for _, association := range vpc.CidrBlockAssociationSet {
+				result.CidrBlocks = append(result.CidrBlocks, aws.ToString(association.CidrBlock))
+			}
+			for _, association := range vpc.Ipv6CidrBlockAssociationSet {
+				result.Ipv6CidrBlocks = append(result.Ipv6CidrBlocks, aws.ToString(association.Ipv6CidrBlock))
+			}
+			vpcs = append(vpcs, result)
+		}
+	}
+	sort.Slice(vpcs, func(i, j int) bool {
+		return vpcs[i].VpcID < vpcs[j].VpcID
+	})
+	return vpcs, nil
+}
+
+// ListSubnets returns the subnets of a region, optionally of one VPC, with free IP counts
+// and whether they route to an internet gateway
+func (s *NetworkService) ListSubnets(region, vpcID string) ([]Subnet, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client := s.EC2.clientForRegion(region)
+	subnets, err := describeSubnets(ctx, client, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	routeTables, err := describeRouteTables(ctx, client, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	applyRouteTables(subnets, routeTables)
+	return subnets, nil
+}
+
+func describeSubnets(ctx context.Context, client *ec2.Client, vpcID string) ([]Subnet, error) {
+	subnets := []Subnet{}
+	paginator := ec2.NewDescribeSubnetsPaginator(client, &ec2.DescribeSubnetsInput{Filters: vpcFilter("vpc-id", vpcID)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe subnets: %w", err)
+		}
+		for _, subnet := range page.Subnets {
+			result := Subnet{
+				SubnetID:            aws.ToString(subnet.SubnetId),
+				VpcID:               aws.ToString(subnet.VpcId),
+				CidrBlock:           aws.ToString(subnet.CidrBlock),
+				Ipv6CidrBlocks:      []string{},
+				AvailabilityZone:    aws.ToString(subnet.AvailabilityZone),
+				State:               string(subnet.State),
+				AvailableIPCount:    aws.ToInt32(subnet.AvailableIpAddressCount),
+				MapPublicIPOnLaunch: aws.ToBool(subnet.MapPublicIpOnLaunch),
+				DefaultForAz:        aws.ToBool(subnet.DefaultForAz),
+				Tags:                tagMap(subnet.Tags),
+			}
+			result.Name = result.Tags["Name"]
+			for _, association := range subnet.Ipv6CidrBlockAssociationSet {
+				result.Ipv6CidrBlocks = append(result.Ipv6CidrBlocks, aws.ToString(association.Ipv6CidrBlock))
+			}
+			result.TotalIPCount = usableIPCount(result.CidrBlock)
+			result.UsedIPCount = max(result.TotalIPCount-int64(result.AvailableIPCount), 0)
+			subnets = append(subnets, result)
+		}
+	}
+	sort.Slice(subnets, func(i, j int) bool {
+		if subnets[i].VpcID != subnets[j].VpcID {
+			return subnets[i].VpcID < subnets[j].VpcID
+		}
+		if subnets[i].AvailabilityZone != subnets[j].AvailabilityZone {
+			return subnets[i].AvailabilityZone < subnets[j].AvailabilityZone
+		}
+		return subnets[i].CidrBlock < subnets[j].CidrBlock
+	})
+	return subnets, nil
+}
+
+// usableIPCount returns the number of addresses in an IPv4 CIDR that instances can use
+func usableIPCount(cidr string) int64 {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil || !prefix.Addr().Is4() {
+		return 0
+	}
+	return max(int64(1)<<(32-prefix.Bits())-reservedSubnetIPs, 0)
+}
+
+// applyRouteTables sets the route table of each subnet, falling back to the main table of
+// its VPC, and marks subnets with a default route to an internet gateway as public
+func applyRouteTables(subnets []Subnet, routeTables []RouteTable) {
+	explicit := make(map[string]RouteTable)
+	mainTables := make(map[string]RouteTable)
+	for _, table := range routeTables {
+		if table.Main {
+			mainTables[table.VpcID] = table
+		}
+		for _, subnetID := range table.SubnetIDs {
+			explicit[subnetID] = table
+		}
+	}
+
+	for i := range subnets {
+		table, ok := explicit[subnets[i].SubnetID]
+		if !ok {
+			if table, ok = mainTables[subnets[i].VpcID]; !ok {
+				continue
+			}
+		}
+		subnets[i].RouteTableID = table.RouteTableID
+		for _, route := range table.Routes {
+			if route.TargetType == "internet-gateway" && (route.Destination == "0.0.0.0/0" || route.Destination == "::/0") {
+				subnets[i].Public = true
+			}
+		}
+	}
+}
+
+// ListRouteTables returns the route tables of a region, optionally of one VPC
+func (s *NetworkService) ListRouteTables(region, vpcID string) ([]RouteTable, error) {
+	return describeRouteTables(context.TODO(), s.EC2.clientForRegion(region), vpcID)
+}
+
+func describeRouteTables(ctx context.Context, client *ec2.Client, vpcID string) ([]RouteTable, error) {
+	routeTables := []RouteTable{}
+	paginator := ec2.NewDescribeRouteTablesPaginator(client, &ec2.DescribeRouteTablesInput{Filters: vpcFilter("vpc-id", vpcID)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe route tables: %w", err)
+		}
+		for _, table := range page.RouteTables {
+			result := RouteTable{
+				RouteTableID: aws.ToString(table.RouteTableId),
+				VpcID:        aws.ToString(table.VpcId),
+				SubnetIDs:    []string{},
+				Routes:       []Route{},
+				Tags:         tagMap(table.Tags),
+			}
+			result.Name = result.Tags["Name"]
+			for _, association := range table.Associations {
+				if aws.ToBool(association.Main) {
+					result.Main = true
+				}
+				if association.SubnetId != nil {
+					result.SubnetIDs = append(result.SubnetIDs, aws.ToString(association.SubnetId))
+				}
+			}
+			for _, route := range table.Routes {
+				result.Routes = append(result.Routes, toRoute(route))
+			}
+			routeTables = append(routeTables, result)
+		}
+	}
+	sort.Slice(routeTables, func(i, j int) bool {
+		if routeTables[i].VpcID != routeTables[j].VpcID {
+			return routeTables[i].VpcID < routeTables[j].VpcID
+		}
+		if routeTables[i].Main != routeTables[j].Main {
+			return routeTables[i].Main
+		}
+		return routeTables[i].RouteTableID < routeTables[j].RouteTableID
+	})
+	return routeTables, nil
+}
+
+func toRoute(route types.Route) Route {
+	result := Route{State: string(route.State)}
+	switch {
+	case route.DestinationCidrBlock != nil:
+		result.Destination = aws.ToString(route.DestinationCidrBlock)
+	case route.DestinationIpv6CidrBlock != nil:
+		result.Destination = aws.ToString(route.DestinationIpv6CidrBlock)
+	default:
+		result.Destination = aws.ToString(route.DestinationPrefixListId)
+	}
+
+	targets := []struct {
+		id         *string
+		targetType string
+	}{
+		{route.NatGatewayId, "nat-gateway"},
+		{route.TransitGatewayId, "transit-gateway"},
+		{route.VpcPeeringConnectionId, "vpc-peering-connection"},
+		{route.EgressOnlyInternetGatewayId, "egress-only-internet-gateway"},
+		{route.CarrierGatewayId, "carrier-gateway"},
+		{route.LocalGatewayId, "local-gateway"},
+		{route.InstanceId, "instance"},
+		{route.NetworkInterfaceId, "network-interface"},
+		{route.GatewayId, "gateway"},
+	}
+	for _, target := range targets {
+		if target.id == nil {
+			continue
+		}
+		result.Target = aws.ToString(target.id)
+		result.TargetType = target.targetType
+		break
+	}
+
+	// GatewayId holds internet gateways, virtual private gateways, VPC endpoints and the local route
+	if result.TargetType == "gateway" {
+		switch {
+		case result.Target == "local":
+			result.TargetType = "local"
+		case strings.HasPrefix(result.Target, "igw-"):
+			result.TargetType = "internet-gateway"
+		case strings.HasPrefix(result.Target, "vgw-"):
+			result.TargetType = "virtual-private-gateway"
+		case strings.HasPrefix(result.Target, "vpce-"):
+			result.TargetType = "vpc-endpoint"
+		}
+	}
+	return result
+}
+
+// ListInternetGateways returns the internet gateways of a region, optionally attached to one VPC
+func (s *NetworkService) ListInternetGateways(region, vpcID string) ([]InternetGateway, error) {
+	return describeInternetGateways(context.TODO(), s.EC2.clientForRegion(region), vpcID)
+}
+
+func describeInternetGateways(ctx context.Context, client *ec2.Client, vpcID string) ([]InternetGateway, error) {
+	gateways := []InternetGateway{}
+	paginator := ec2.NewDescribeInternetGatewaysPaginator(client, &ec2.DescribeInternetGatewaysInput{
+		Filters: vpcFilter("attachment.vpc-id", vpcID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe internet gateways: %w", err)
+		}
+		for _, gateway := range page.InternetGateways {
+			result := InternetGateway{
+				InternetGatewayID: aws.ToString(gateway.InternetGatewayId),
+				VpcIDs:            []string{},
+				Tags:              tagMap(gateway.Tags),
+			}
+			result.Name = result.Tags["Name"]
+			for _, attachment := range gateway.Attachments {
+				result.VpcIDs = append(result.VpcIDs, aws.ToString(attachment.VpcId))
+			}
+			gateways = append(gateways, result)
+		}
+	}
+	return gateways, nil
+}
+
+// ListNatGateways returns the NAT gateways of a region, optionally of one VPC. Deleted gateways are left out.
+func (s *NetworkService) ListNatGateways(region, vpcID string) ([]NatGateway, error) {
+	return describeNatGateways(context.TODO(), s.EC2.clientForRegion(region), vpcID)
+}
+
+func describeNatGateways(ctx context.Context, client *ec2.Client, vpcID string) ([]NatGateway, error) {
+	filters := append(vpcFilter("vpc-id", vpcID), types.Filter{
+		Name:   aws.String("state"),
+		Values: []string{"pending", "available", "failed", "deleting"},
+	})
+
+	gateways := []NatGateway{}
+	paginator := ec2.NewDescribeNatGatewaysPaginator(client, &ec2.DescribeNatGatewaysInput{Filter: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe NAT gateways: %w", err)
+		}
+		for _, gateway := range page.NatGateways {
+			result := NatGateway{
+				NatGatewayID:     aws.ToString(gateway.NatGatewayId),
+				VpcID:            aws.ToString(gateway.VpcId),
+				SubnetID:         aws.ToString(gateway.SubnetId),
+				State:            string(gateway.State),
+				ConnectivityType: string(gateway.ConnectivityType),
+				CreateTime:       formatTime(gateway.CreateTime),
+				Tags:             tagMap(gateway.Tags),
+			}
+			result.Name = result.Tags["Name"]
+			for _, address := range gateway.NatGatewayAddresses {
+				if aws.ToBool(address.IsPrimary) || result.PrivateIP == "" {
+					result.PublicIP = aws.ToString(address.PublicIp)
+					result.PrivateIP = aws.ToString(address.PrivateIp)
+				}
+			}
+			gateways = append(gateways, result)
+		}
+	}
+	return gateways, nil
+}
+
+// ListNetworkInterfaces returns the network interfaces of a region, optionally of one VPC or subnet
+func (s *NetworkService) ListNetworkInterfaces(region, vpcID, subnetID string) ([]NetworkInterface, error) {
+	filters := vpcFilter("vpc-id", vpcID)
+	if subnetID != "" {
+		filters = append(filters, types.Filter{Name: aws.String("subnet-id"), Values: []string{subnetID}})
+	}
+
+	interfaces := []NetworkInterface{}
+	paginator := ec2.NewDescribeNetworkInterfacesPaginator(s.EC2.clientForRegion(region), &ec2.DescribeNetworkInterfacesInput{
+		Filters: filters,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe network interfaces: %w", err)
+		}
+		for _, networkInterface := range page.NetworkInterfaces {
+			result := NetworkInterface{
+				NetworkInterfaceID: aws.ToString(networkInterface.NetworkInterfaceId),
+				Description:        aws.ToString(networkInterface.Description),
+				InterfaceType:      string(networkInterface.InterfaceType),
+				Status:             string(networkInterface.Status),
+				VpcID:              aws.ToString(networkInterface.VpcId),
+				SubnetID:           aws.ToString(networkInterface.SubnetId),
+				AvailabilityZone:   aws.ToString(networkInterface.AvailabilityZone),
+				PrivateIP:          aws.ToString(networkInterface.PrivateIpAddress),
+				PrivateIPs:         []string{},
+				MacAddress:         aws.ToString(networkInterface.MacAddress),
+				SecurityGroupIDs:   []string{},
+				RequesterManaged:   aws.ToBool(networkInterface.RequesterManaged),
+			}
+			for _, address := range networkInterface.PrivateIpAddresses {
+				result.PrivateIPs = append(result.PrivateIPs, aws.ToString(address.PrivateIpAddress))
+			}
+			if networkInterface.Association != nil {
+				result.PublicIP = aws.ToString(networkInterface.Association.PublicIp)
+			}
+			if networkInterface.Attachment != nil {
+				result.InstanceID = aws.ToString(networkInterface.Attachment.InstanceId)
+			}
+			for _, group := range networkInterface.Groups {
+				result.SecurityGroupIDs = append(result.SecurityGroupIDs, aws.ToString(group.GroupId))
+			}
+			interfaces = append(interfaces, result)
+		}
+	}
+	return interfaces, nil
+}
+
+// Topology builds the VPC → subnet → instance graph of a region, optionally of one VPC,
+// with internet gateways attached to VPCs and NAT gateways inside subnets
+func (s *NetworkService) Topology(region, vpcID string) (*TopologyGraph, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	client := s.EC2.clientForRegion(region)
+	vpcs, err := describeVpcs(ctx, client, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	subnets, err := describeSubnets(ctx, client, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	routeTables, err := describeRouteTables(ctx, client, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	applyRouteTables(subnets, routeTables)
+	internetGateways, err := describeInternetGateways(ctx, client, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	natGateways, err := describeNatGateways(ctx, client, vpcID)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &TopologyGraph{
+		Region:      client.Options().Region,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Nodes:       []TopologyNode{},
+		Edges:       []TopologyEdge{},
+	}
+	nodes := make(map[string]bool)
+	addNode := func(node TopologyNode) {
+		nodes[node.ID] = true
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	addEdge := func(source, target, relation string) {
+		if nodes[source] && nodes[target] {
+			graph.Edges = append(graph.Edges, TopologyEdge{Source: source, Target: target, Relation: relation})
+		}
+	}
+
+	for _, vpc := range vpcs {
+		addNode(TopologyNode{
+			ID:    vpc.VpcID,
+			Type:  "vpc",
+			Label: labelOr(vpc.Name, vpc.VpcID),
+			Data: map[string]string{
+				"cidrBlock": strings.Join(vpc.CidrBlocks, ","),
+				"state":     vpc.State,
+				"isDefault": fmt.Sprint(vpc.IsDefault),
+			},
+		})
+	}
+	for _, subnet := range subnets {
+		addNode(TopologyNode{
+			ID:    subnet.SubnetID,
+			Type:  "subnet",
+			Label: labelOr(subnet.Name, subnet.SubnetID),
+			Data: map[string]string{
+				"cidrBlock":        subnet.CidrBlock,
+				"availabilityZone": subnet.AvailabilityZone,
+				"availableIpCount": fmt.Sprint(subnet.AvailableIPCount),
+				"public":           fmt.Sprint(subnet.Public),
+				"routeTableId":     subnet.RouteTableID,
+			},
+		})
+		addEdge(subnet.VpcID, subnet.SubnetID, "contains")
+	}
+	for _, gateway := range internetGateways {
+		addNode(TopologyNode{
+			ID:    gateway.InternetGatewayID,
+			Type:  "internet-gateway",
+			Label: labelOr(gateway.Name, gateway.InternetGatewayID),
+			Data:  map[string]string{},
+		})
+		for _, attachedVpc := range gateway.VpcIDs {
+			addEdge(gateway.InternetGatewayID, attachedVpc, "attached")
+		}
+	}
+	for _, gateway := range natGateways {
+		addNode(TopologyNode{
+			ID:    gateway.NatGatewayID,
+			Type:  "nat-gateway",
+			Label: labelOr(gateway.Name, gateway.NatGatewayID),
+			Data: map[string]string{
+				"state":            gateway.State,
+				"connectivityType": gateway.ConnectivityType,
+				"publicIp":         gateway.PublicIP,
+			},
+		})
+		addEdge(gateway.SubnetID, gateway.NatGatewayID, "contains")
+	}
+
+	filters := append(vpcFilter("vpc-id", vpcID), types.Filter{
+		Name:   aws.String("instance-state-name"),
+		Values: []string{"pending", "running", "stopping", "stopped"},
+	})
+	paginator := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{Filters: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe instances: %w", err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				instanceID := aws.ToString(instance.InstanceId)
+				addNode(TopologyNode{
+					ID:    instanceID,
+					Type:  "instance",
+					Label: labelOr(tagMap(instance.Tags)["Name"], instanceID),
+					Data: map[string]string{
+						"state":        string(instance.State.Name),
+						"instanceType": string(instance.InstanceType),
+						"privateIp":    aws.ToString(instance.PrivateIpAddress),
+						"publicIp":     aws.ToString(instance.PublicIpAddress),
+					},
+				})
+				addEdge(aws.ToString(instance.SubnetId), instanceID, "contains")
+			}
+		}
+	}
+	return graph, nil
+}
+
+func labelOr(name, id string) string {
+	if name != "" {
+		return name
+	}
+	return id
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+)
+
+// LaunchPreflight is the outcome of checking a launch request without starting any instances
+type LaunchPreflight struct {
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+	Region   string   `json:"region"`
+	DryRun   string   `json:"dryRun"` // passed, failed or skipped
+}
+
+// PreflightLaunch checks a launch request against the target region and returns every problem found.
+// The AWS dry run stops at its first error, so it only runs once the individual checks pass and
+// catches what they cannot, such as missing permissions or exhausted quotas.
+func (s *EC2Service) PreflightLaunch(input LaunchInstanceInput) (*LaunchPreflight, error) {
+	client := s.clientForRegion(input.Region)
+	result := &LaunchPreflight{
+		Problems: []string{},
+		Region:   client.Options().Region,
+		DryRun:   "skipped",
+	}
+
+	var validationErr *ValidationError
+	if err := input.Validate(); errors.As(err, &validationErr) {
+		result.Problems = append(result.Problems, validationErr.Problems...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var architecture string
+	if input.AMI != "" {
+		architecture = checkImage(ctx, client, input.AMI, &result.Problems)
+	}
+	if input.InstanceType != "" {
+		checkInstanceType(ctx, client, input.InstanceType, architecture, &result.Problems)
+	}
+
+	var vpcID, zone string
+	if input.SubnetID != "" {
+		vpcID, zone = checkSubnet(ctx, client, input.SubnetID, &result.Problems)
+	}
+	if input.InstanceType != "" && zone != "" {
+		checkZoneOffering(ctx, client, input.InstanceType, zone, &result.Problems)
+	}
+	if input.KeyPair != "" {
+		checkKeyPair(ctx, client, input.KeyPair, &result.Problems)
+	}
+	if len(input.SecurityGroups) > 0 {
+		checkSecurityGroups(ctx, client, input.SecurityGroups, vpcID, &result.Problems)
+	}
+
+	if len(result.Problems) == 0 {
+		runInput := input.runInstancesInput()
+		runInput.DryRun = aws.Bool(true)
+		_, err := client.RunInstances(ctx, runInput)
+		if err == nil || isAPIError(err, "DryRunOperation") {
+			result.DryRun = "passed"
+		} else {
+			result.DryRun = "failed"
+			result.Problems = append(result.Problems, "dry run: "+apiErrorMessage(err))
+		}
+	}
+
+	result.Valid = len(result.Problems) == 0
+	return result, nil
+}
+
+// checkImage verifies the AMI exists and is available, and returns its architecture
+func checkImage(ctx context.Context, client *ec2.Client, imageID string, problems *[]string) string {
+	output, err := client.DescribeImages(ctx, &ec2.DescribeImagesInput{ImageIds: []string{imageID}})
+	if err != nil {
+		if isAPIError(err, "InvalidAMIID.NotFound", "InvalidAMIID.Malformed", "InvalidAMIID.Unavailable") {
+			*problems = append(*problems, fmt.Sprintf("AMI %s does not exist in this region", imageID))
+		} else {
+			*problems = append(*problems, "unable to check AMI: "+apiErrorMessage(err))
+		}
+		return ""
+	}
+	if len(output.Images) == 0 {
+		*problems = append(*problems, fmt.Sprintf("AMI %s does not exist in this region", imageID))
+		return ""
+	}
+
+	image := output.Images[0]
+	if image.State != types.ImageStateAvailable {
+		*problems = append(*problems, fmt.Sprintf("AMI %s is %s, not available", imageID, image.State))
+	}
+	return string(image.Architecture)
+}
+
+// checkInstanceType verifies the instance type exists and supports the AMI architecture
+func checkInstanceType(ctx context.Context, client *ec2.Client, instanceType, architecture string, problems *[]string) {
+	output, err := client.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []types.InstanceType{types.InstanceType(instanceType)},
+	})
+	if err != nil || len(output.InstanceTypes) == 0 {
+		if err == nil || isAPIError(err, "InvalidInstanceType") {
+			*problems = append(*problems, fmt.Sprintf("instance type %s is not available in this region", instanceType))
+		} else {
+			*problems = append(*problems, "unable to check instance type: "+apiErrorMessage(err))
+		}
+		return
+	}
+
+	info := output.InstanceTypes[0]
+	if architecture == "" || info.ProcessorInfo == nil {
+		return
+	}
+	supported := make([]string, 0, len(info.ProcessorInfo.SupportedArchitectures))
+	for _, arch := range info.ProcessorInfo.SupportedArchitectures {
+		supported = append(supported, string(arch))
+	}
+	if !slices.Contains(supported, architecture) {
+		*problems = append(*problems, fmt.Sprintf("instance type %s supports %v but the AMI is %s", instanceType, supported, architecture))
+	}
+}
+
+// checkSubnet verifies the subnet exists and returns its VPC and availability zone
+func checkSubnet(ctx context.Context, client *ec2.Client, subnetID string, problems *[]string) (string, string) {
+	output, err := client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{SubnetIds: []string{subnetID}})
+	if err != nil || len(output.Subnets) == 0 {
+		if err == nil || isAPIError(err, "InvalidSubnetID.NotFound", "InvalidSubnetID.Malformed") {
+			*problems = append(*problems, fmt.Sprintf("subnet %s does not exist in this region", subnetID))
+		} else {
+			*problems = append(*problems, "unable to check subnet: "+apiErrorMessage(err))
+		}
+		return "", ""
+	}
+
+	subnet := output.Subnets[0]
+	return aws.ToString(subnet.VpcId), aws.ToString(subnet.AvailabilityZone)
+}
+
+// checkZoneOffering verifies the instance type is offered in the subnet's availability zone
+func checkZoneOffering(ctx context.Context, client *ec2.Client, instanceType, zone string, problems *[]string) {
+	output, err := client.DescribeInstanceTypeOfferings(ctx, &ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: types.LocationTypeAvailabilityZone,
+		Filters: []types.Filter{
+			{Name: aws.String("instance-type"), Values: []string{instanceType}},
+			{Name: aws.String("location"), Values: []string{zone}},
+		},
+	})
+	if err != nil {
+		*problems = append(*problems, "unable to check instance type offerings: "+apiErrorMessage(err))
+		return
+	}
+	if len(output.InstanceTypeOfferings) == 0 {
+		*problems = append(*problems, fmt.Sprintf("instance type %s is not offered in %s", instanceType, zone))
+	}
+}
+
+func checkKeyPair(ctx context.Context, client *ec2.Client, keyName string, problems *[]string) {
+	_, err := client.DescribeKeyPairs(ctx, &ec2.DescribeKeyPairsInput{KeyNames: []string{keyName}})
+	if err == nil {
+		return
+	}
+	if isAPIError(err, "InvalidKeyPair.NotFound") {
+		*problems = append(*problems, fmt.Sprintf("key pair %s does not exist in this region", keyName))
+	} else {
+		*problems = append(*problems, "unable to check key pair: "+apiErrorMessage(err))
+	}
+}
+
+// checkSecurityGroups verifies each group exists and, when a subnet is given, belongs to its VPC
+func checkSecurityGroups(ctx context.Context, client *ec2.Client, groupIDs []string, vpcID string, problems *[]string) {
+	// Describe the groups one at a time; a batch call fails on the first unknown ID
+	for _, groupID := range groupIDs {
+		output, err := client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{GroupIds: []string{groupID}})
+		if err != nil || len(output.SecurityGroups) == 0 {
+			if err == nil || isAPIError(err, "InvalidGroup.NotFound", "InvalidGroupId.Malformed") {
+				*problems = append(*problems, fmt.Sprintf("security group %s does not exist in this region", groupID))
+			} else {
+				*problems = append(*problems, "unable to check security group: "+apiErrorMessage(err))
+			}
+			continue
+		}
+
+		if groupVPC := aws.ToString(output.SecurityGroups[0].VpcId); vpcID != "" && groupVPC != vpcID {
+			*problems = append(*problems, fmt.Sprintf("security group %s belongs to %s, not the subnet's VPC %s", groupID, groupVPC, vpcID))
+		}
+	}
+}
+
+// apiErrorMessage returns the AWS error message without the SDK's operation and request details
+func apiErrorMessage(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return fmt.Sprintf("%s: %s", apiErr.ErrorCode(), apiErr.ErrorMessage())
+	}
+	return err.Error()
+}
@@ -0,0 +1,497 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// sensitivePorts are services that should never be reachable from the whole internet
+var sensitivePorts = map[int32]string{
+	21:    "FTP",
+	22:    "SSH",
+	23:    "Telnet",
+	135:   "Windows RPC",
+	445:   "SMB",
+	1433:  "SQL Server",
+	1521:  "Oracle",
+	2375:  "Docker API",
+	3306:  "MySQL",
+	3389:  "RDP",
+	5432:  "PostgreSQL",
+	5601:  "Kibana",
+	5984:  "CouchDB",
+	6379:  "Redis",
+	9042:  "Cassandra",
+	9200:  "Elasticsearch",
+	11211: "Memcached",
+	27017: "MongoDB",
+}
+
+// SecurityGroup is a security group with its inbound and outbound rules
+type SecurityGroup struct {
+	GroupID       string              `json:"groupId"`
+	GroupName     string              `json:"groupName"`
+	Description   string              `json:"description"`
+	VpcID         string              `json:"vpcId"`
+	OwnerID       string              `json:"ownerId"`
+	InboundRules  []SecurityGroupRule `json:"inboundRules"`
+	OutboundRules []SecurityGroupRule `json:"outboundRules"`
+	Tags          map[string]string   `json:"tags"`
+}
+
+// SecurityGroupRule is a single rule with one source or destination
+type SecurityGroupRule struct {
+	RuleID            string `json:"ruleId"`
+	Protocol          string `json:"protocol"` // tcp, udp, icmp, icmpv6 or all
+	FromPort          int32  `json:"fromPort"` // -1 for all protocols
+	ToPort            int32  `json:"toPort"`
+	CidrIPv4          string `json:"cidrIpv4,omitempty"`
+	CidrIPv6          string `json:"cidrIpv6,omitempty"`
+	PrefixListID      string `json:"prefixListId,omitempty"`
+	ReferencedGroupID string `json:"referencedGroupId,omitempty"`
+	Description       string `json:"description"`
+}
+
+// CreateSecurityGroupInput creates a security group in a VPC
+type CreateSecurityGroupInput struct {
+	Region      string            `json:"region"`
+	GroupName   string            `json:"groupName"`
+	Description string            `json:"description"`
+	VpcID       string            `json:"vpcId"` // defaults to the default VPC
+	Tags        map[string]string `json:"tags"`
+}
+
+// Validate checks the input
+func (input *CreateSecurityGroupInput) Validate() error {
+	var problems []string
+	if input.GroupName == "" || len(input.GroupName) > 255 {
+		problems = append(problems, "groupName must be between 1 and 255 characters")
+	}
+	if strings.HasPrefix(strings.ToLower(input.GroupName), "sg-") {
+		problems = append(problems, "groupName cannot start with sg-")
+	}
+	if input.Description == "" || len(input.Description) > 255 {
+		problems = append(problems, "description must be between 1 and 255 characters")
+	}
+	return validationError(problems)
+}
+
+// RuleSpec describes a rule to authorize; exactly one of Cidr, SourceGroupID or PrefixListID is required
+type RuleSpec struct {
+	Protocol      string `json:"protocol"` // tcp, udp, icmp, icmpv6 or all
+	FromPort      int32  `json:"fromPort"`
+	ToPort        int32  `json:"toPort"`
+	Cidr          string `json:"cidr"` // IPv4 or IPv6
+	SourceGroupID string `json:"sourceGroupId"`
+	PrefixListID  string `json:"prefixListId"`
+	Description   string `json:"description"`
+}
+
+// AuthorizeRulesInput adds rules to a security group
+type AuthorizeRulesInput struct {
+	Region    string     `json:"region"`
+	GroupID   string     `json:"groupId"`
+	Direction string     `json:"direction"` // inbound or outbound
+	Rules     []RuleSpec `json:"rules"`
+}
+
+// Validate checks the input
+func (input *AuthorizeRulesInput) Validate() error {
+	var problems []string
+	if input.GroupID == "" {
+		problems = append(problems, "groupId is required")
+	}
+	if input.Direction != "inbound" && input.Direction != "outbound" {
+		problems = append(problems, "direction must be inbound or outbound")
+	}
+	if len(input.Rules) == 0 {
+		problems = append(problems, "at least one rule is required")
+	}
+	for i, rule := range input.Rules {
+		problems = append(problems, rule.validate(i)...)
+	}
+	return validationError(problems)
+}
+
+func (rule RuleSpec) validate(index int) []string {
+	var problems []string
+	field := fmt.Sprintf("rules[%d]", index)
+
+	switch rule.Protocol {
+	case "tcp", "udp":
+		if rule.FromPort < 0 || rule.ToPort > 65535 || rule.FromPort > rule.ToPort {
+			problems = append(problems, field+": ports must be between 0 and 65535 with fromPort not greater than toPort")
+		}
+	case "icmp", "icmpv6":
+		if rule.FromPort < -1 || rule.FromPort > 255 || rule.ToPort < -1 || rule.ToPort > 255 {
+			problems = append(problems, field+": icmp type (fromPort) and code (toPort) must be between -1 and 255")
+		}
+	case "all":
+	default:
+		problems = append(problems, fmt.Sprintf("%s: unsupported protocol %q", field, rule.Protocol))
+	}
+
+	sources := 0
+	for _, source := range []string{rule.Cidr, rule.SourceGroupID, rule.PrefixListID} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		problems = append(problems, field+": exactly one of cidr, sourceGroupId or prefixListId is required")
+	}
+	if rule.Cidr != "" {
+		if _, err := netip.ParsePrefix(rule.Cidr); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid cidr %q", field, rule.Cidr))
+		}
+	}
+	if len(rule.Description) > 255 {
+		problems = append(problems, field+": description must be at most 255 characters")
+	}
+	return problems
+}
+
+// ipPermission converts a validated rule into the form the EC2 API expects
+func (rule RuleSpec) ipPermission() types.IpPermission {
+	permission := types.IpPermission{IpProtocol: aws.String(rule.Protocol)}
+	if rule.Protocol == "all" {
+		permission.IpProtocol = aws.String("-1")
+	} else {
+		permission.FromPort = aws.Int32(rule.FromPort)
+		permission.ToPort = aws.Int32(rule.ToPort)
+	}
+
+	var description *string
+	if rule.Description != "" {
+		description = aws.String(rule.Description)
+	}
+	switch {
+	case rule.Cidr != "":
+		if prefix, _ := netip.ParsePrefix(rule.Cidr); prefix.Addr().Is6() {
+			permission.Ipv6Ranges = []types.Ipv6Range{{CidrIpv6: aws.String(rule.Cidr), Description: description}}
+		} else {
+			permission.IpRanges = []types.IpRange{{CidrIp: aws.String(rule.Cidr), Description: description}}
+		}
+	case rule.SourceGroupID != "":
+		permission.UserIdGroupPairs = []types.UserIdGroupPair{{GroupId: aws.String(rule.SourceGroupID), Description: description}}
+	case rule.PrefixListID != "":
+		permission.PrefixListIds = []types.PrefixListId{{PrefixListId: aws.String(rule.PrefixListID), Description: description}}
+	}
+	return permission
+}
+
+// RevokeRulesInput removes rules from a security group by rule ID
+type RevokeRulesInput struct {
+	Region    string   `json:"region"`
+	GroupID   string   `json:"groupId"`
+	Direction string   `json:"direction"` // inbound or outbound
+	RuleIDs   []string `json:"ruleIds"`
+}
+
+// Validate checks the input
+func (input *RevokeRulesInput) Validate() error {
+	var problems []string
+	if input.GroupID == "" {
+		problems = append(problems, "groupId is required")
+	}
+	if input.Direction != "inbound" && input.Direction != "outbound" {
+		problems = append(problems, "direction must be inbound or outbound")
+	}
+	if len(input.RuleIDs) == 0 {
+		problems = append(problems, "at least one rule ID is required")
+	}
+	return validationError(problems)
+}
+
+// SecurityGroupFinding is an inbound rule that exposes a sensitive port to the whole internet
+type SecurityGroupFinding struct {
+	GroupID   string `json:"groupId"`
+	GroupName string `json:"groupName"`
+	VpcID     string `json:"vpcId"`
+	RuleID    string `json:"ruleId"`
+	Protocol  string `json:"protocol"`
+	FromPort  int32  `json:"fromPort"`
+	ToPort    int32  `json:"toPort"`
+	Source    string `json:"source"` // 0.0.0.0/0 or ::/0
+	Severity  string `json:"severity"`
+	Issue     string `json:"issue"`
+}
+
+// ListSecurityGroups returns the security groups of a region, optionally of one VPC, with their rules
+func (s *EC2Service) ListSecurityGroups(region, vpcID string) ([]SecurityGroup, error) {
+	var filters []types.Filter
+	if vpcID != "" {
+		filters = []types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcID}}}
+	}
+	return s.describeSecurityGroups(context.TODO(), s.clientForRegion(region), &ec2.DescribeSecurityGroupsInput{Filters: filters})
+}
+
+// GetSecurityGroup returns a security group with its rules
+func (s *EC2Service) GetSecurityGroup(region, groupID string) (*SecurityGroup, error) {
+	groups, err := s.describeSecurityGroups(context.TODO(), s.clientForRegion(region), &ec2.DescribeSecurityGroupsInput{
+		GroupIds: []string{groupID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("security group %s not found", groupID)
+	}
+	return &groups[0], nil
+}
+
+func (s *EC2Service) describeSecurityGroups(ctx context.Context, client *ec2.Client, input *ec2.DescribeSecurityGroupsInput) ([]SecurityGroup, error) {
+	groups := []SecurityGroup{}
+	index := make(map[string]int)
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe security groups: %w", err)
+		}
+		for _, group := range page.SecurityGroups {
+			index[aws.ToString(group.GroupId)] = len(groups)
+			groups = append(groups, SecurityGroup{
+				GroupID:       aws.ToString(group.GroupId),
+				GroupName:     aws.ToString(group.GroupName),
+				Description:   aws.ToString(group.Description),
+				VpcID:         aws.ToString(group.VpcId),
+				OwnerID:       aws.ToString(group.OwnerId),
+				InboundRules:  []SecurityGroupRule{},
+				OutboundRules: []SecurityGroupRule{},
+				Tags:          tagMap(group.Tags),
+			})
+		}
+	}
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	// Security group rules carry the rule IDs needed to revoke them; the group-id filter takes up to 200 values
+	groupIDs := make([]string, 0, len(groups))
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.GroupID)
+	}
+	for offset := 0; offset < len(groupIDs); offset += 200 {
+		rules := ec2.NewDescribeSecurityGroupRulesPaginator(client, &ec2.DescribeSecurityGroupRulesInput{
+			Filters: []types.Filter{{Name: aws.String("group-id"), Values: groupIDs[offset:min(offset+200, len(groupIDs))]}},
+		})
+		for rules.HasMorePages() {
+			page, err := rules.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to describe security group rules: %w", err)
+			}
+			for _, rule := range page.SecurityGroupRules {
+				i, ok := index[aws.ToString(rule.GroupId)]
+				if !ok {
+					continue
+				}
+				if aws.ToBool(rule.IsEgress) {
+					groups[i].OutboundRules = append(groups[i].OutboundRules, toSecurityGroupRule(rule))
+				} else {
+					groups[i].InboundRules = append(groups[i].InboundRules, toSecurityGroupRule(rule))
+				}
+			}
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].VpcID != groups[j].VpcID {
+			return groups[i].VpcID < groups[j].VpcID
+		}
+		return groups[i].GroupName < groups[j].GroupName
+	})
+	return groups, nil
+}
+
+// CreateSecurityGroup creates a security group and returns its ID. New groups allow all outbound traffic and no inbound traffic.
+func (s *EC2Service) CreateSecurityGroup(input CreateSecurityGroupInput) (string, error) {
+	createInput := &ec2.CreateSecurityGroupInput{
+		GroupName:   aws.String(input.GroupName),
+		Description: aws.String(input.Description),
+	}
+	if input.VpcID != "" {
+		createInput.VpcId = aws.String(input.VpcID)
+	}
+	if len(input.Tags) > 0 {
+		tags := make([]types.Tag, 0, len(input.Tags))
+		for _, key := range sortedKeys(input.Tags) {
+			tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(input.Tags[key])})
+		}
+		createInput.TagSpecifications = []types.TagSpecification{{ResourceType: types.ResourceTypeSecurityGroup, Tags: tags}}
+	}
+
+	output, err := s.clientForRegion(input.Region).CreateSecurityGroup(context.TODO(), createInput)
+	if err != nil {
+		return "", fmt.Errorf("unable to create security group: %w", err)
+	}
+	return aws.ToString(output.GroupId), nil
+}
+
+// DeleteSecurityGroup deletes a security group; it must not be in use by an instance, network interface or another group's rule
+func (s *EC2Service) DeleteSecurityGroup(region, groupID string) error {
+	_, err := s.clientForRegion(region).DeleteSecurityGroup(context.TODO(), &ec2.DeleteSecurityGroupInput{
+		GroupId: aws.String(groupID),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete security group: %w", err)
+	}
+	return nil
+}
+
+// AuthorizeRules adds rules to a security group and returns the created rules
+func (s *EC2Service) AuthorizeRules(input AuthorizeRulesInput) ([]SecurityGroupRule, error) {
+	permissions := make([]types.IpPermission, len(input.Rules))
+	for i, rule := range input.Rules {
+		permissions[i] = rule.ipPermission()
+	}
+
+	client := s.clientForRegion(input.Region)
+	var created []types.SecurityGroupRule
+	if input.Direction == "inbound" {
+		output, err := client.AuthorizeSecurityGroupIngress(context.TODO(), &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(input.GroupID),
+			IpPermissions: permissions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to authorize inbound rules: %w", err)
+		}
+		created = output.SecurityGroupRules
+	} else {
+		output, err := client.AuthorizeSecurityGroupEgress(context.TODO(), &ec2.AuthorizeSecurityGroupEgressInput{
+			GroupId:       aws.String(input.GroupID),
+			IpPermissions: permissions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to authorize outbound rules: %w", err)
+		}
+		created = output.SecurityGroupRules
+	}
+
+	rules := make([]SecurityGroupRule, len(created))
+	for i, rule := range created {
+		rules[i] = toSecurityGroupRule(rule)
+	}
+	return rules, nil
+}
+
+// RevokeRules removes rules from a security group by rule ID
+func (s *EC2Service) RevokeRules(input RevokeRulesInput) error {
+	client := s.clientForRegion(input.Region)
+	if input.Direction == "inbound" {
+		_, err := client.RevokeSecurityGroupIngress(context.TODO(), &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:              aws.String(input.GroupID),
+			SecurityGroupRuleIds: input.RuleIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to revoke inbound rules: %w", err)
+		}
+		return nil
+	}
+
+	_, err := client.RevokeSecurityGroupEgress(context.TODO(), &ec2.RevokeSecurityGroupEgressInput{
+		GroupId:              aws.String(input.GroupID),
+		SecurityGroupRuleIds: input.RuleIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to revoke outbound rules: %w", err)
+	}
+	return nil
+}
+
+// FindOpenSecurityGroupRules reports inbound rules that open all traffic or a sensitive port
+// to 0.0.0.0/0 or ::/0, most severe first
+func (s *EC2Service) FindOpenSecurityGroupRules(region string) ([]SecurityGroupFinding, error) {
+	groups, err := s.ListSecurityGroups(region, "")
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []SecurityGroupFinding{}
+	for _, group := range groups {
+		for _, rule := range group.InboundRules {
+			source := rule.CidrIPv4
+			if source != "0.0.0.0/0" {
+				source = rule.CidrIPv6
+			}
+			if source != "0.0.0.0/0" && source != "::/0" {
+				continue
+			}
+
+			finding := SecurityGroupFinding{
+				GroupID:   group.GroupID,
+				GroupName: group.GroupName,
+				VpcID:     group.VpcID,
+				RuleID:    rule.RuleID,
+				Protocol:  rule.Protocol,
+				FromPort:  rule.FromPort,
+				ToPort:    rule.ToPort,
+				Source:    source,
+			}
+			switch {
+			case rule.Protocol == "all":
+				finding.Severity = "critical"
+				finding.Issue = "all traffic is open to the internet"
+			case rule.Protocol == "tcp" || rule.Protocol == "udp":
+				services := exposedServices(rule.FromPort, rule.ToPort)
+				if len(services) == 0 {
+					continue
+				}
+				finding.Severity = "high"
+				finding.Issue = fmt.Sprintf("%s open to the internet", strings.Join(services, ", "))
+			default:
+				continue
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity == "critical" && findings[j].Severity != "critical"
+	})
+	return findings, nil
+}
+
+// exposedServices names the sensitive ports inside a port range
+func exposedServices(fromPort, toPort int32) []string {
+	ports := make([]int32, 0, len(sensitivePorts))
+	for port := range sensitivePorts {
+		if port >= fromPort && port <= toPort {
+			ports = append(ports, port)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+
+	services := make([]string, len(ports))
+	for i, port := range ports {
+		services[i] = fmt.Sprintf("%s (%d)", sensitivePorts[port], port)
+	}
+	return services
+}
+
+func toSecurityGroupRule(rule types.SecurityGroupRule) SecurityGroupRule {
+	result := SecurityGroupRule{
+		RuleID:       aws.ToString(rule.SecurityGroupRuleId),
+		Protocol:     aws.ToString(rule.IpProtocol),
+		FromPort:     aws.ToInt32(rule.FromPort),
+		ToPort:       aws.ToInt32(rule.ToPort),
+		CidrIPv4:     aws.ToString(rule.CidrIpv4),
+		CidrIPv6:     aws.ToString(rule.CidrIpv6),
+		PrefixListID: aws.ToString(rule.PrefixListId),
+		Description:  aws.ToString(rule.Description),
+	}
+	if result.Protocol == "-1" {
+		result.Protocol = "all"
+	}
+	if rule.ReferencedGroupInfo != nil {
+		result.ReferencedGroupID = aws.ToString(rule.ReferencedGroupInfo.GroupId)
+	}
+	return result
+}
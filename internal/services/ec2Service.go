@@ -3,49 +3,79 @@ package services
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 )
 
 // EC2Service encapsulates EC2 operations
 type EC2Service struct {
 	Client *ec2.Client
+	SSM    *ssm.Client // resolves the latest public AMIs
+
+	instanceTypes *ttlCache[[]InstanceTypeInfo]
 }
 
-type LaunchInstanceInput struct {
-	AMI            string   `json:"ami"`
-	InstanceType   string   `json:"instanceType"`
-	KeyPair        string   `json:"keyPair"`
-	SecurityGroups []string `json:"securityGroups"`
-	InstanceName   string   `json:"instanceName"`
-	MinCount       int32    `json:"minCount"`
-	MaxCount       int32    `json:"maxCount"`
-	Region         string   `json:"region"`
+// NewEC2Service initializes the EC2Service
+func NewEC2Service(client *ec2.Client) *EC2Service {
+	return &EC2Service{
+		Client:        client,
+		instanceTypes: newTTLCache[[]InstanceTypeInfo](instanceTypeTTL),
+	}
 }
 
 type InstanceStatus struct {
-	Name      string `json:"name"`
-	ID        string `json:"id"`
-	State     string `json:"state"`
-	PublicIP  string `json:"public_ip"`
-	PrivateIP string `json:"private_ip"`
+	Name             string `json:"name"`
+	ID               string `json:"id"`
+	State            string `json:"state"`
+	PublicIP         string `json:"public_ip"`
+	PrivateIP        string `json:"private_ip"`
+	InstanceType     string `json:"instance_type"`
+	LaunchTime       string `json:"launch_time"`
+	Lifecycle        string `json:"lifecycle"` // on-demand, spot or scheduled
+	SpotRequestID    string `json:"spot_request_id,omitempty"`
+	SpotRequestState string `json:"spot_request_state,omitempty"`
+	SpotStatus       string `json:"spot_status,omitempty"`
+	SpotInterruption string `json:"spot_interruption,omitempty"` // pending or interrupted
+
+	ElasticIPAllocationID string `json:"elastic_ip_allocation_id,omitempty"` // set when PublicIP is an Elastic IP
 }
 
 type InstanceDetail struct {
-	ID             string   `json:"id"`
-	Name           string   `json:"name"`
-	State          string   `json:"state"`
-	PrivateIP      string   `json:"privateIp"`
-	PublicIP       string   `json:"publicIp"`
-	InstanceType   string   `json:"instanceType"`
-	LaunchTime     string   `json:"launchTime"`
-	Tags           []string `json:"tags"`
-	SecurityGroups []string `json:"securityGroups"`
-	Volumes        []string `json:"volumes"`
+	ID                 string            `json:"id"`
+	Name               string            `json:"name"`
+	State              string            `json:"state"`
+	PrivateIP          string            `json:"privateIp"`
+	PublicIP           string            `json:"publicIp"`
+	InstanceType       string            `json:"instanceType"`
+	LaunchTime         string            `json:"launchTime"`
+	Tags               []string          `json:"tags"`
+	SecurityGroups     []string          `json:"securityGroups"`
+	Volumes            []string          `json:"volumes"`
+	VolumeDetails      []Volume          `json:"volumeDetails"`
+	ImageID            string            `json:"imageId"`
+	KeyName            string            `json:"keyName"`
+	SecurityGroupIDs   []string          `json:"securityGroupIds"`
+	SubnetID           string            `json:"subnetId"`
+	AvailabilityZone   string            `json:"availabilityZone"`
+	IamInstanceProfile string            `json:"iamInstanceProfile"`
+	Monitoring         bool              `json:"monitoring"`
+	EbsOptimized       bool              `json:"ebsOptimized"`
+	TagMap             map[string]string `json:"tagMap"`
+}
+
+// clientForRegion returns a client for the given region, or the default client when region is empty
+func (s *EC2Service) clientForRegion(region string) *ec2.Client {
+	if region == "" || region == s.Client.Options().Region {
+		return s.Client
+	}
+	return ec2.New(s.Client.Options(), func(o *ec2.Options) {
+		o.Region = region
+	})
 }
 
 func (s *EC2Service) ListRegions() ([]types.Region, error) {
@@ -56,68 +86,6 @@ func (s *EC2Service) ListRegions() ([]types.Region, error) {
 	return output.Regions, nil
 }
 
-func (s *EC2Service) ListSecurityGroups() ([]map[string]string, error) {
-	req := &ec2.DescribeSecurityGroupsInput{}
-	resp, err := s.Client.DescribeSecurityGroups(context.TODO(), req)
-	if err != nil {
-		return nil, fmt.Errorf("unable to describe security groups: %v", err)
-	}
-
-	var result []map[string]string
-	for _, sg := range resp.SecurityGroups {
-		result = append(result, map[string]string{
-			"GroupId":   *sg.GroupId,
-			"GroupName": *sg.GroupName,
-		})
-	}
-
-	return result, nil
-}
-
-func (s *EC2Service) LaunchInstance(input LaunchInstanceInput) (string, error) {
-	region := input.Region
-
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
-	if err != nil {
-		return "", fmt.Errorf("unable to load SDK config, %v", err)
-	}
-	s.Client = ec2.NewFromConfig(cfg)
-
-	// Prepare the EC2 run instance input
-	runInput := &ec2.RunInstancesInput{
-		ImageId:          aws.String(input.AMI),
-		InstanceType:     types.InstanceType(input.InstanceType),
-		KeyName:          aws.String(input.KeyPair),
-		MinCount:         aws.Int32(input.MinCount),
-		MaxCount:         aws.Int32(input.MaxCount),
-		SecurityGroupIds: input.SecurityGroups,
-		TagSpecifications: []types.TagSpecification{
-			{
-				ResourceType: types.ResourceTypeInstance,
-				Tags: []types.Tag{
-					{
-						Key:   aws.String("Name"),
-						Value: aws.String(input.InstanceName),
-					},
-				},
-			},
-		},
-	}
-
-	// Run the instance
-	output, err := s.Client.RunInstances(context.TODO(), runInput)
-	if err != nil {
-		return "", fmt.Errorf("failed to launch instance: %w", err)
-	}
-
-	// Return the instance ID if successful
-	if len(output.Instances) > 0 {
-		return aws.ToString(output.Instances[0].InstanceId), nil
-	}
-
-	return "", fmt.Errorf("no instances were launched")
-}
-
 func (s *EC2Service) StopInstanceById(instanceID string) (string, error) {
 
 	input := &ec2.StopInstancesInput{
@@ -203,28 +171,45 @@ func (s *EC2Service) GetAllRunningInstancesStatus() ([]InstanceStatus, error) {
 					}
 				}
 
+				lifecycle := "on-demand"
+				if instance.InstanceLifecycle != "" {
+					lifecycle = string(instance.InstanceLifecycle)
+				}
+
 				// Append the instance info to the runningInstances slice
 				runningInstances = append(runningInstances, InstanceStatus{
-					Name:      instanceName,
-					ID:        aws.ToString(instance.InstanceId),
-					State:     string(instance.State.Name),
-					PublicIP:  aws.ToString(instance.PublicIpAddress),
-					PrivateIP: aws.ToString(instance.PrivateIpAddress),
+					Name:          instanceName,
+					ID:            aws.ToString(instance.InstanceId),
+					State:         string(instance.State.Name),
+					PublicIP:      aws.ToString(instance.PublicIpAddress),
+					PrivateIP:     aws.ToString(instance.PrivateIpAddress),
+					InstanceType:  string(instance.InstanceType),
+					LaunchTime:    formatTime(instance.LaunchTime),
+					Lifecycle:     lifecycle,
+					SpotRequestID: aws.ToString(instance.SpotInstanceRequestId),
 				})
 			}
 		}
 	}
 
+	s.addSpotDetails(context.TODO(), runningInstances)
+	s.addElasticIPDetails(context.TODO(), runningInstances)
+
 	return runningInstances, nil
 }
 
 func (s *EC2Service) GetInstanceDetails(instanceId string) (*InstanceDetail, error) {
+	return s.instanceDetails(s.Client, instanceId)
+}
+
+// instanceDetails describes an instance with the client of the region it runs in
+func (s *EC2Service) instanceDetails(client *ec2.Client, instanceId string) (*InstanceDetail, error) {
 	// Create the request to describe the instance
 	input := &ec2.DescribeInstancesInput{
 		InstanceIds: []string{instanceId},
 	}
 
-	output, err := s.Client.DescribeInstances(context.TODO(), input)
+	output, err := client.DescribeInstances(context.TODO(), input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe instance: %v", err)
 	}
@@ -241,6 +226,21 @@ func (s *EC2Service) GetInstanceDetails(instanceId string) (*InstanceDetail, err
 		PrivateIP:    aws.ToString(instance.PrivateIpAddress),
 		PublicIP:     aws.ToString(instance.PublicIpAddress),
 		InstanceType: string(instance.InstanceType),
+		ImageID:      aws.ToString(instance.ImageId),
+		KeyName:      aws.ToString(instance.KeyName),
+		SubnetID:     aws.ToString(instance.SubnetId),
+		EbsOptimized: aws.ToBool(instance.EbsOptimized),
+		TagMap:       tagMap(instance.Tags),
+	}
+
+	if instance.Placement != nil {
+		instanceDetail.AvailabilityZone = aws.ToString(instance.Placement.AvailabilityZone)
+	}
+	if instance.IamInstanceProfile != nil {
+		instanceDetail.IamInstanceProfile = aws.ToString(instance.IamInstanceProfile.Arn)
+	}
+	if instance.Monitoring != nil {
+		instanceDetail.Monitoring = instance.Monitoring.State == types.MonitoringStateEnabled
 	}
 
 	// Convert LaunchTime (*time.Time) to string
@@ -259,12 +259,25 @@ func (s *EC2Service) GetInstanceDetails(instanceId string) (*InstanceDetail, err
 	// Fetch security groups associated with the instance
 	for _, sg := range instance.SecurityGroups {
 		instanceDetail.SecurityGroups = append(instanceDetail.SecurityGroups, *sg.GroupName)
+		instanceDetail.SecurityGroupIDs = append(instanceDetail.SecurityGroupIDs, aws.ToString(sg.GroupId))
 	}
 
 	// Fetch attached volumes (if any)
 	for _, blockDevice := range instance.BlockDeviceMappings {
+		if blockDevice.Ebs == nil {
+			continue
+		}
 		instanceDetail.Volumes = append(instanceDetail.Volumes, *blockDevice.Ebs.VolumeId)
 	}
+	instanceDetail.VolumeDetails = []Volume{}
+	if len(instanceDetail.Volumes) > 0 {
+		volumes, err := describeVolumes(context.TODO(), client, &ec2.DescribeVolumesInput{VolumeIds: instanceDetail.Volumes})
+		if err != nil {
+			log.Printf("unable to describe volumes of %s: %v", instanceId, err)
+		} else {
+			instanceDetail.VolumeDetails = volumes
+		}
+	}
 
 	// Fetch instance tags
 	for _, tag := range instance.Tags {
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const (
+	defaultSpotProduct = "Linux/UNIX"
+	maxSpotHistoryDays = 90
+)
+
+// SpotOptions requests spot capacity for a launch
+type SpotOptions struct {
+	MaxPrice             string `json:"maxPrice"`             // USD per hour; empty caps at the on-demand price
+	InterruptionBehavior string `json:"interruptionBehavior"` // terminate (default), stop or hibernate
+}
+
+func (o *SpotOptions) validate() []string {
+	var problems []string
+	if o.MaxPrice != "" {
+		if price, err := strconv.ParseFloat(o.MaxPrice, 64); err != nil || price <= 0 {
+			problems = append(problems, "spot.maxPrice must be a positive number")
+		}
+	}
+
+	switch o.InterruptionBehavior {
+	case "":
+		o.InterruptionBehavior = "terminate"
+	case "terminate", "stop", "hibernate":
+	default:
+		problems = append(problems, "spot.interruptionBehavior must be terminate, stop or hibernate")
+	}
+	return problems
+}
+
+// marketOptions converts the options for RunInstances. Stopping or hibernating on interruption
+// needs a persistent request so the instance can be resumed later.
+func (o *SpotOptions) marketOptions() *types.InstanceMarketOptionsRequest {
+	spot := &types.SpotMarketOptions{
+		InstanceInterruptionBehavior: types.InstanceInterruptionBehavior(o.InterruptionBehavior),
+		SpotInstanceType:             types.SpotInstanceTypeOneTime,
+	}
+	if o.InterruptionBehavior != "terminate" {
+		spot.SpotInstanceType = types.SpotInstanceTypePersistent
+	}
+	if o.MaxPrice != "" {
+		spot.MaxPrice = aws.String(o.MaxPrice)
+	}
+	return &types.InstanceMarketOptionsRequest{
+		MarketType:  types.MarketTypeSpot,
+		SpotOptions: spot,
+	}
+}
+
+// SpotPrice is the spot price of an instance type in an availability zone at a point in time
+type SpotPrice struct {
+	InstanceType       string  `json:"instance_type"`
+	AvailabilityZone   string  `json:"availability_zone"`
+	ProductDescription string  `json:"product_description"`
+	Price              float64 `json:"price"`
+	Timestamp          string  `json:"timestamp"`
+}
+
+// SpotPriceHistory holds the latest price per instance type and zone, and every change in the window
+type SpotPriceHistory struct {
+	Region  string      `json:"region"`
+	Current []SpotPrice `json:"current"`
+	History []SpotPrice `json:"history"`
+}
+
+// GetSpotPriceHistory returns spot prices for the last days days, optionally narrowed to
+// an instance type and availability zone. Without an instance type a region has thousands of
+// price changes a day, so only the current prices of the last day are returned.
+func (s *EC2Service) GetSpotPriceHistory(region, instanceType, zone, product string, days int) (*SpotPriceHistory, error) {
+	if days <= 0 || instanceType == "" {
+		days = 1
+	}
+	days = min(days, maxSpotHistoryDays)
+	if product == "" {
+		product = defaultSpotProduct
+	}
+
+	client := s.clientForRegion(region)
+	input := &ec2.DescribeSpotPriceHistoryInput{
+		StartTime:           aws.Time(time.Now().AddDate(0, 0, -days)),
+		ProductDescriptions: []string{product},
+	}
+	if instanceType != "" {
+		input.InstanceTypes = []types.InstanceType{types.InstanceType(instanceType)}
+	}
+	if zone != "" {
+		input.AvailabilityZone = aws.String(zone)
+	}
+
+	result := &SpotPriceHistory{
+		Region:  client.Options().Region,
+		Current: []SpotPrice{},
+		History: []SpotPrice{},
+	}
+	latest := make(map[string]SpotPrice)
+
+	paginator := ec2.NewDescribeSpotPriceHistoryPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe spot price history: %w", err)
+		}
+
+		for _, entry := range page.SpotPriceHistory {
+			price, _ := strconv.ParseFloat(aws.ToString(entry.SpotPrice), 64)
+			spotPrice := SpotPrice{
+				InstanceType:       string(entry.InstanceType),
+				AvailabilityZone:   aws.ToString(entry.AvailabilityZone),
+				ProductDescription: string(entry.ProductDescription),
+				Price:              price,
+				Timestamp:          formatTime(entry.Timestamp),
+			}
+			if instanceType != "" {
+				result.History = append(result.History, spotPrice)
+			}
+
+			key := spotPrice.InstanceType + "/" + spotPrice.AvailabilityZone
+			if current, ok := latest[key]; !ok || spotPrice.Timestamp > current.Timestamp {
+				latest[key] = spotPrice
+			}
+		}
+	}
+
+	for _, price := range latest {
+		result.Current = append(result.Current, price)
+	}
+	sort.Slice(result.Current, func(i, j int) bool {
+		if result.Current[i].InstanceType != result.Current[j].InstanceType {
+			return result.Current[i].InstanceType < result.Current[j].InstanceType
+		}
+		return result.Current[i].AvailabilityZone < result.Current[j].AvailabilityZone
+	})
+	sort.SliceStable(result.History, func(i, j int) bool {
+		return result.History[i].Timestamp > result.History[j].Timestamp
+	})
+
+	return result, nil
+}
+
+// addSpotDetails fills in the spot request state and interruption status of spot instances.
+// Failures are logged rather than returned so the instance list still loads.
+func (s *EC2Service) addSpotDetails(ctx context.Context, instances []InstanceStatus) {
+	var requestIDs []string
+	for _, instance := range instances {
+		if instance.SpotRequestID != "" {
+			requestIDs = append(requestIDs, instance.SpotRequestID)
+		}
+	}
+	if len(requestIDs) == 0 {
+		return
+	}
+
+	output, err := s.Client.DescribeSpotInstanceRequests(ctx, &ec2.DescribeSpotInstanceRequestsInput{
+		SpotInstanceRequestIds: requestIDs,
+	})
+	if err != nil {
+		log.Printf("unable to describe spot instance requests: %v", err)
+		return
+	}
+
+	requests := make(map[string]types.SpotInstanceRequest, len(output.SpotInstanceRequests))
+	for _, request := range output.SpotInstanceRequests {
+		requests[aws.ToString(request.SpotInstanceRequestId)] = request
+	}
+
+	for i := range instances {
+		request, ok := requests[instances[i].SpotRequestID]
+		if !ok {
+			continue
+		}
+		instances[i].SpotRequestState = string(request.State)
+		if request.Status != nil {
+			instances[i].SpotStatus = aws.ToString(request.Status.Code)
+			instances[i].SpotInterruption = spotInterruption(instances[i].SpotStatus)
+		}
+	}
+}
+
+// Spot request status codes that mean AWS is about to reclaim, or has reclaimed, the instance.
+// Codes such as instance-terminated-by-user or instance-terminated-by-schedule are not interruptions.
+var (
+	spotPendingInterruptionCodes = map[string]bool{
+		"marked-for-stop":                      true,
+		"marked-for-termination":               true,
+		"marked-for-hibernation":               true,
+		"marked-for-stop-by-experiment":        true,
+		"marked-for-termination-by-experiment": true,
+	}
+	spotInterruptedCodes = map[string]bool{
+		"instance-terminated-by-price":                true,
+		"instance-terminated-no-capacity":             true,
+		"instance-terminated-capacity-oversubscribed": true,
+		"instance-terminated-launch-group-constraint": true,
+		"instance-terminated-by-experiment":           true,
+		"instance-stopped-by-price":                   true,
+		"instance-stopped-no-capacity":                true,
+		"instance-stopped-capacity-oversubscribed":    true,
+		"instance-stopped-by-experiment":              true,
+		"instance-hibernated-by-price":                true,
+		"instance-hibernated-no-capacity":             true,
+		"instance-hibernated-capacity-oversubscribed": true,
+	}
+)
+
+// spotInterruption classifies a spot request status code as a pending or completed interruption
+func spotInterruption(code string) string {
+	switch {
+	case spotPendingInterruptionCodes[code]:
+		return "pending"
+	case spotInterruptedCodes[code]:
+		return "interrupted"
+	}
+	return ""
+}
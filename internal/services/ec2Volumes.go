@@ -0,0 +1,561 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Volume describes an EBS volume and where it is attached
+type Volume struct {
+	VolumeID         string             `json:"volumeId"`
+	Name             string             `json:"name"`
+	Size             int32              `json:"size"` // GiB
+	VolumeType       string             `json:"volumeType"`
+	Iops             int32              `json:"iops"`
+	Throughput       int32              `json:"throughput"`
+	State            string             `json:"state"`
+	AvailabilityZone string             `json:"availabilityZone"`
+	Encrypted        bool               `json:"encrypted"`
+	KmsKeyID         string             `json:"kmsKeyId"`
+	SnapshotID       string             `json:"snapshotId"`
+	CreateTime       string             `json:"createTime"`
+	Attachments      []VolumeAttachment `json:"attachments"`
+	Tags             map[string]string  `json:"tags"`
+}
+
+// VolumeAttachment is an instance a volume is attached to
+type VolumeAttachment struct {
+	InstanceID          string `json:"instanceId"`
+	Device              string `json:"device"`
+	State               string `json:"state"`
+	DeleteOnTermination bool   `json:"deleteOnTermination"`
+}
+
+// VolumeFilter narrows the volume list; zero values match everything
+type VolumeFilter struct {
+	Region     string
+	InstanceID string
+	State      string
+	VolumeType string
+}
+
+// CreateVolumeInput creates an empty volume or one restored from a snapshot
+type CreateVolumeInput struct {
+	Region           string            `json:"region"`
+	AvailabilityZone string            `json:"availabilityZone"`
+	Size             int32             `json:"size"` // GiB, defaults to the snapshot size
+	VolumeType       string            `json:"volumeType"`
+	Iops             int32             `json:"iops"`
+	Throughput       int32             `json:"throughput"`
+	SnapshotID       string            `json:"snapshotId"`
+	Encrypted        bool              `json:"encrypted"`
+	KmsKeyID         string            `json:"kmsKeyId"`
+	Name             string            `json:"name"`
+	Tags             map[string]string `json:"tags"`
+}
+
+// Validate checks the input and fills in defaults
+func (input *CreateVolumeInput) Validate() error {
+	var problems []string
+	if input.AvailabilityZone == "" {
+		problems = append(problems, "availabilityZone is required")
+	}
+	if input.Size == 0 && input.SnapshotID == "" {
+		problems = append(problems, "size is required unless a snapshotId is given")
+	}
+	if input.Size < 0 || input.Size > 65536 {
+		problems = append(problems, "size must be between 1 and 65536 GiB")
+	}
+	if input.VolumeType == "" {
+		input.VolumeType = "gp3"
+	}
+	problems = append(problems, volumeSpecProblems("volume", input.VolumeType, input.Iops, input.Throughput)...)
+	if input.KmsKeyID != "" && !input.Encrypted {
+		problems = append(problems, "kmsKeyId requires encrypted to be true")
+	}
+	if _, ok := input.Tags["Name"]; ok && input.Name != "" {
+		problems = append(problems, "set the Name tag through name only")
+	}
+	return validationError(problems)
+}
+
+// AttachVolumeInput attaches a volume to an instance in the same availability zone
+type AttachVolumeInput struct {
+	Region     string `json:"region"`
+	VolumeID   string `json:"volumeId"`
+	InstanceID string `json:"instanceId"`
+	Device     string `json:"device"` // e.g. /dev/sdf
+}
+
+// Validate checks the input
+func (input *AttachVolumeInput) Validate() error {
+	var problems []string
+	if input.VolumeID == "" {
+		problems = append(problems, "volumeId is required")
+	}
+	if input.InstanceID == "" {
+		problems = append(problems, "instanceId is required")
+	}
+	if input.Device == "" {
+		problems = append(problems, "device is required")
+	}
+	return validationError(problems)
+}
+
+// ModifyVolumeInput resizes a volume or changes its type and performance; zero values keep the current setting
+type ModifyVolumeInput struct {
+	Region     string `json:"region"`
+	VolumeID   string `json:"volumeId"`
+	Size       int32  `json:"size"`
+	VolumeType string `json:"volumeType"`
+	Iops       int32  `json:"iops"`
+	Throughput int32  `json:"throughput"`
+}
+
+// VolumeModification reports the progress of a volume modification
+type VolumeModification struct {
+	VolumeID         string `json:"volumeId"`
+	State            string `json:"state"` // modifying, optimizing, completed or failed
+	OriginalSize     int32  `json:"originalSize"`
+	TargetSize       int32  `json:"targetSize"`
+	OriginalType     string `json:"originalType"`
+	TargetType       string `json:"targetType"`
+	TargetIops       int32  `json:"targetIops"`
+	TargetThroughput int32  `json:"targetThroughput"`
+	Progress         int64  `json:"progress"`
+}
+
+// Snapshot describes an EBS snapshot owned by this account
+type Snapshot struct {
+	SnapshotID  string            `json:"snapshotId"`
+	Name        string            `json:"name"`
+	VolumeID    string            `json:"volumeId"`
+	VolumeSize  int32             `json:"volumeSize"` // GiB
+	State       string            `json:"state"`
+	Progress    string            `json:"progress"`
+	StartTime   string            `json:"startTime"`
+	Description string            `json:"description"`
+	Encrypted   bool              `json:"encrypted"`
+	Tags        map[string]string `json:"tags"`
+}
+
+// CreateSnapshotInput snapshots a volume
+type CreateSnapshotInput struct {
+	Region      string `json:"region"`
+	VolumeID    string `json:"volumeId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// OrphanedSnapshot is a snapshot whose source volume is gone and that no AMI uses
+type OrphanedSnapshot struct {
+	Snapshot
+	Reason string `json:"reason"`
+}
+
+// EBSCleanupReport lists storage that is likely no longer needed
+type EBSCleanupReport struct {
+	Region               string             `json:"region"`
+	GeneratedAt          string             `json:"generatedAt"`
+	UnattachedVolumes    []Volume           `json:"unattachedVolumes"`
+	UnattachedVolumeGiB  int64              `json:"unattachedVolumeGiB"`
+	OrphanedSnapshots    []OrphanedSnapshot `json:"orphanedSnapshots"`
+	OrphanedSnapshotGiB  int64              `json:"orphanedSnapshotGiB"`
+	SnapshotsChecked     int                `json:"snapshotsChecked"`
+	SnapshotsUsedByImage int                `json:"snapshotsUsedByImages"`
+}
+
+// ListVolumes returns the volumes of a region that match the filter, largest first
+func (s *EC2Service) ListVolumes(filter VolumeFilter) ([]Volume, error) {
+	input := &ec2.DescribeVolumesInput{}
+	if filter.InstanceID != "" {
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String("attachment.instance-id"), Values: []string{filter.InstanceID}})
+	}
+	if filter.State != "" {
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String("status"), Values: []string{filter.State}})
+	}
+	if filter.VolumeType != "" {
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String("volume-type"), Values: []string{filter.VolumeType}})
+	}
+
+	volumes, err := describeVolumes(context.TODO(), s.clientForRegion(filter.Region), input)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		if volumes[i].Size != volumes[j].Size {
+			return volumes[i].Size > volumes[j].Size
+		}
+		return volumes[i].VolumeID < volumes[j].VolumeID
+	})
+	return volumes, nil
+}
+
+// GetVolume returns the details of a volume
+func (s *EC2Service) GetVolume(region, volumeID string) (*Volume, error) {
+	volumes, err := describeVolumes(context.TODO(), s.clientForRegion(region), &ec2.DescribeVolumesInput{
+		VolumeIds: []string{volumeID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(volumes) == 0 {
+		return nil, fmt.Errorf("volume %s not found", volumeID)
+	}
+	return &volumes[0], nil
+}
+
+func describeVolumes(ctx context.Context, client *ec2.Client, input *ec2.DescribeVolumesInput) ([]Volume, error) {
+	volumes := []Volume{}
+	paginator := ec2.NewDescribeVolumesPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe volumes: %w", err)
+		}
+		for _, volume := range page.Volumes {
+			volumes = append(volumes, toVolume(volume))
+		}
+	}
+	return volumes, nil
+}
+
+// CreateVolume creates a volume from a validated input and returns its ID
+func (s *EC2Service) CreateVolume(input CreateVolumeInput) (string, error) {
+	createInput := &ec2.CreateVolumeInput{
+		AvailabilityZone: aws.String(input.AvailabilityZone),
+		VolumeType:       types.VolumeType(input.VolumeType),
+		Encrypted:        aws.Bool(input.Encrypted),
+	}
+	if input.Size > 0 {
+		createInput.Size = aws.Int32(input.Size)
+	}
+	if input.Iops > 0 {
+		createInput.Iops = aws.Int32(input.Iops)
+	}
+	if input.Throughput > 0 {
+		createInput.Throughput = aws.Int32(input.Throughput)
+	}
+	if input.SnapshotID != "" {
+		createInput.SnapshotId = aws.String(input.SnapshotID)
+	}
+	if input.KmsKeyID != "" {
+		createInput.KmsKeyId = aws.String(input.KmsKeyID)
+	}
+
+	var tags []types.Tag
+	if input.Name != "" {
+		tags = append(tags, types.Tag{Key: aws.String("Name"), Value: aws.String(input.Name)})
+	}
+	for _, key := range sortedKeys(input.Tags) {
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(input.Tags[key])})
+	}
+	if len(tags) > 0 {
+		createInput.TagSpecifications = []types.TagSpecification{{ResourceType: types.ResourceTypeVolume, Tags: tags}}
+	}
+
+	output, err := s.clientForRegion(input.Region).CreateVolume(context.TODO(), createInput)
+	if err != nil {
+		return "", fmt.Errorf("unable to create volume: %w", err)
+	}
+	return aws.ToString(output.VolumeId), nil
+}
+
+// AttachVolume attaches a volume to an instance and returns the attachment state
+func (s *EC2Service) AttachVolume(input AttachVolumeInput) (string, error) {
+	output, err := s.clientForRegion(input.Region).AttachVolume(context.TODO(), &ec2.AttachVolumeInput{
+		VolumeId:   aws.String(input.VolumeID),
+		InstanceId: aws.String(input.InstanceID),
+		Device:     aws.String(input.Device),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to attach volume: %w", err)
+	}
+	return string(output.State), nil
+}
+
+// DetachVolume detaches a volume from its instance. Force skips a clean unmount
+// and can corrupt the file system, so it is only for instances that are not responding.
+func (s *EC2Service) DetachVolume(region, volumeID string, force bool) (string, error) {
+	output, err := s.clientForRegion(region).DetachVolume(context.TODO(), &ec2.DetachVolumeInput{
+		VolumeId: aws.String(volumeID),
+		Force:    aws.Bool(force),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to detach volume: %w", err)
+	}
+	return string(output.State), nil
+}
+
+// ModifyVolume resizes a volume or changes its type, IOPS or throughput.
+// Volumes can only grow; the file system has to be extended on the instance afterwards.
+func (s *EC2Service) ModifyVolume(input ModifyVolumeInput) (*VolumeModification, error) {
+	if input.VolumeID == "" {
+		return nil, validationError([]string{"volumeId is required"})
+	}
+	current, err := s.GetVolume(input.Region, input.VolumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	if input.Size == 0 && input.VolumeType == "" && input.Iops == 0 && input.Throughput == 0 {
+		problems = append(problems, "at least one of size, volumeType, iops or throughput is required")
+	}
+	if input.Size != 0 && input.Size < current.Size {
+		problems = append(problems, fmt.Sprintf("size cannot shrink from %d GiB to %d GiB", current.Size, input.Size))
+	}
+	if input.Size > 65536 {
+		problems = append(problems, "size must be at most 65536 GiB")
+	}
+	targetType := input.VolumeType
+	if targetType == "" {
+		targetType = current.VolumeType
+	}
+	// An io1/io2 volume keeps its provisioned iops unless new ones are given, so iops is
+	// only required when the type changes to io1 or io2
+	targetIops := input.Iops
+	if targetIops == 0 && targetType == current.VolumeType && (targetType == "io1" || targetType == "io2") {
+		targetIops = current.Iops
+	}
+	problems = append(problems, volumeSpecProblems("volume", targetType, targetIops, input.Throughput)...)
+	if err := validationError(problems); err != nil {
+		return nil, err
+	}
+
+	modifyInput := &ec2.ModifyVolumeInput{VolumeId: aws.String(input.VolumeID)}
+	if input.Size > 0 {
+		modifyInput.Size = aws.Int32(input.Size)
+	}
+	if input.VolumeType != "" {
+		modifyInput.VolumeType = types.VolumeType(input.VolumeType)
+	}
+	if input.Iops > 0 {
+		modifyInput.Iops = aws.Int32(input.Iops)
+	}
+	if input.Throughput > 0 {
+		modifyInput.Throughput = aws.Int32(input.Throughput)
+	}
+
+	output, err := s.clientForRegion(input.Region).ModifyVolume(context.TODO(), modifyInput)
+	if err != nil {
+		return nil, fmt.Errorf("unable to modify volume: %w", err)
+	}
+
+	modification := &VolumeModification{VolumeID: input.VolumeID}
+	if m := output.VolumeModification; m != nil {
+		modification.State = string(m.ModificationState)
+		modification.OriginalSize = aws.ToInt32(m.OriginalSize)
+		modification.TargetSize = aws.ToInt32(m.TargetSize)
+		modification.OriginalType = string(m.OriginalVolumeType)
+		modification.TargetType = string(m.TargetVolumeType)
+		modification.TargetIops = aws.ToInt32(m.TargetIops)
+		modification.TargetThroughput = aws.ToInt32(m.TargetThroughput)
+		modification.Progress = aws.ToInt64(m.Progress)
+	}
+	return modification, nil
+}
+
+// DeleteVolume deletes a volume; it must be detached first
+func (s *EC2Service) DeleteVolume(region, volumeID string) error {
+	_, err := s.clientForRegion(region).DeleteVolume(context.TODO(), &ec2.DeleteVolumeInput{
+		VolumeId: aws.String(volumeID),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete volume: %w", err)
+	}
+	return nil
+}
+
+// ListSnapshots returns the snapshots owned by this account, optionally of one volume, newest first
+func (s *EC2Service) ListSnapshots(region, volumeID string) ([]Snapshot, error) {
+	input := &ec2.DescribeSnapshotsInput{OwnerIds: []string{"self"}}
+	if volumeID != "" {
+		input.Filters = []types.Filter{{Name: aws.String("volume-id"), Values: []string{volumeID}}}
+	}
+
+	snapshots, err := describeSnapshots(context.TODO(), s.clientForRegion(region), input)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].StartTime > snapshots[j].StartTime
+	})
+	return snapshots, nil
+}
+
+func describeSnapshots(ctx context.Context, client *ec2.Client, input *ec2.DescribeSnapshotsInput) ([]Snapshot, error) {
+	snapshots := []Snapshot{}
+	paginator := ec2.NewDescribeSnapshotsPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe snapshots: %w", err)
+		}
+		for _, snapshot := range page.Snapshots {
+			snapshots = append(snapshots, toSnapshot(snapshot))
+		}
+	}
+	return snapshots, nil
+}
+
+// CreateSnapshot starts a snapshot of a volume and returns its ID
+func (s *EC2Service) CreateSnapshot(input CreateSnapshotInput) (string, error) {
+	if input.VolumeID == "" {
+		return "", validationError([]string{"volumeId is required"})
+	}
+
+	createInput := &ec2.CreateSnapshotInput{
+		VolumeId:    aws.String(input.VolumeID),
+		Description: aws.String(input.Description),
+	}
+	if input.Name != "" {
+		createInput.TagSpecifications = []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeSnapshot,
+				Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String(input.Name)}},
+			},
+		}
+	}
+
+	output, err := s.clientForRegion(input.Region).CreateSnapshot(context.TODO(), createInput)
+	if err != nil {
+		return "", fmt.Errorf("unable to create snapshot: %w", err)
+	}
+	return aws.ToString(output.SnapshotId), nil
+}
+
+// DeleteSnapshot deletes a snapshot; snapshots backing a registered AMI cannot be deleted
+func (s *EC2Service) DeleteSnapshot(region, snapshotID string) error {
+	_, err := s.clientForRegion(region).DeleteSnapshot(context.TODO(), &ec2.DeleteSnapshotInput{
+		SnapshotId: aws.String(snapshotID),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete snapshot: %w", err)
+	}
+	return nil
+}
+
+// FindUnusedStorage reports available (unattached) volumes and snapshots whose source
+// volume no longer exists and that are not referenced by any AMI owned by this account
+func (s *EC2Service) FindUnusedStorage(region string) (*EBSCleanupReport, error) {
+	client := s.clientForRegion(region)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	volumes, err := describeVolumes(ctx, client, &ec2.DescribeVolumesInput{})
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := describeSnapshots(ctx, client, &ec2.DescribeSnapshotsInput{OwnerIds: []string{"self"}})
+	if err != nil {
+		return nil, err
+	}
+
+	usedByImages := make(map[string]bool)
+	images := ec2.NewDescribeImagesPaginator(client, &ec2.DescribeImagesInput{Owners: []string{"self"}})
+	for images.HasMorePages() {
+		page, err := images.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe images: %w", err)
+		}
+		for _, image := range page.Images {
+			for _, mapping := range image.BlockDeviceMappings {
+				if mapping.Ebs != nil && mapping.Ebs.SnapshotId != nil {
+					usedByImages[*mapping.Ebs.SnapshotId] = true
+				}
+			}
+		}
+	}
+
+	report := &EBSCleanupReport{
+		Region:            client.Options().Region,
+		GeneratedAt:       time.Now().UTC().Format(time.RFC3339),
+		UnattachedVolumes: []Volume{},
+		OrphanedSnapshots: []OrphanedSnapshot{},
+		SnapshotsChecked:  len(snapshots),
+	}
+
+	existingVolumes := make(map[string]bool, len(volumes))
+	for _, volume := range volumes {
+		existingVolumes[volume.VolumeID] = true
+		if volume.State == string(types.VolumeStateAvailable) {
+			report.UnattachedVolumes = append(report.UnattachedVolumes, volume)
+			report.UnattachedVolumeGiB += int64(volume.Size)
+		}
+	}
+
+	for _, snapshot := range snapshots {
+		if usedByImages[snapshot.SnapshotID] {
+			report.SnapshotsUsedByImage++
+			continue
+		}
+		if existingVolumes[snapshot.VolumeID] {
+			continue
+		}
+		report.OrphanedSnapshots = append(report.OrphanedSnapshots, OrphanedSnapshot{
+			Snapshot: snapshot,
+			Reason:   "source volume no longer exists and no AMI uses the snapshot",
+		})
+		report.OrphanedSnapshotGiB += int64(snapshot.VolumeSize)
+	}
+
+	sort.Slice(report.UnattachedVolumes, func(i, j int) bool {
+		return report.UnattachedVolumes[i].Size > report.UnattachedVolumes[j].Size
+	})
+	sort.Slice(report.OrphanedSnapshots, func(i, j int) bool {
+		return report.OrphanedSnapshots[i].StartTime < report.OrphanedSnapshots[j].StartTime
+	})
+	return report, nil
+}
+
+func toVolume(volume types.Volume) Volume {
+	result := Volume{
+		VolumeID:         aws.ToString(volume.VolumeId),
+		Size:             aws.ToInt32(volume.Size),
+		VolumeType:       string(volume.VolumeType),
+		Iops:             aws.ToInt32(volume.Iops),
+		Throughput:       aws.ToInt32(volume.Throughput),
+		State:            string(volume.State),
+		AvailabilityZone: aws.ToString(volume.AvailabilityZone),
+		Encrypted:        aws.ToBool(volume.Encrypted),
+		KmsKeyID:         aws.ToString(volume.KmsKeyId),
+		SnapshotID:       aws.ToString(volume.SnapshotId),
+		CreateTime:       formatTime(volume.CreateTime),
+		Attachments:      []VolumeAttachment{},
+		Tags:             tagMap(volume.Tags),
+	}
+	result.Name = result.Tags["Name"]
+
+	for _, attachment := range volume.Attachments {
+		result.Attachments = append(result.Attachments, VolumeAttachment{
+			InstanceID:          aws.ToString(attachment.InstanceId),
+			Device:              aws.ToString(attachment.Device),
+			State:               string(attachment.State),
+			DeleteOnTermination: aws.ToBool(attachment.DeleteOnTermination),
+		})
+	}
+	return result
+}
+
+func toSnapshot(snapshot types.Snapshot) Snapshot {
+	result := Snapshot{
+		SnapshotID:  aws.ToString(snapshot.SnapshotId),
+		VolumeID:    aws.ToString(snapshot.VolumeId),
+		VolumeSize:  aws.ToInt32(snapshot.VolumeSize),
+		State:       string(snapshot.State),
+		Progress:    aws.ToString(snapshot.Progress),
+		StartTime:   formatTime(snapshot.StartTime),
+		Description: aws.ToString(snapshot.Description),
+		Encrypted:   aws.ToBool(snapshot.Encrypted),
+		Tags:        tagMap(snapshot.Tags),
+	}
+	result.Name = result.Tags["Name"]
+	return result
+}
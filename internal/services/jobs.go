@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// finishedJobTTL is how long a finished job is kept for its result to be fetched
+	finishedJobTTL = 24 * time.Hour
+	// maxFinishedJobs caps how many finished jobs are kept, dropping the oldest first
+	maxFinishedJobs = 100
+)
+
+// JobStatus describes the lifecycle state of a background job
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// JobInfo is a point-in-time snapshot of a background job
+type JobInfo struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Status     JobStatus   `json:"status"`
+	StartedAt  string      `json:"started_at"`
+	FinishedAt string      `json:"finished_at,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Progress   interface{} `json:"progress,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+}
+
+// Job is a cancellable unit of work running in the background
+type Job struct {
+	mu         sync.Mutex
+	info       JobInfo
+	cancel     context.CancelFunc
+	finishedAt time.Time // zero while running
+}
+
+// SetProgress publishes the latest progress value of the job
+func (j *Job) SetProgress(progress interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.info.Progress = progress
+}
+
+func (j *Job) snapshot() JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.info
+}
+
+// JobFunc is the body of a background job. The context is cancelled when the job is cancelled.
+type JobFunc func(ctx context.Context, job *Job) (interface{}, error)
+
+// JobManager keeps track of background jobs. Finished jobs are dropped after
+// finishedJobTTL, or sooner once more than maxFinishedJobs have finished.
+type JobManager struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewJobManager initializes the JobManager
+func NewJobManager() *JobManager {
+	return &JobManager{
+		jobs: make(map[string]*Job),
+	}
+}
+
+// Start runs fn in a new goroutine and returns a snapshot of the created job
+func (m *JobManager) Start(jobType string, fn JobFunc) JobInfo {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	job := &Job{
+		info: JobInfo{
+			ID:        newJobID(),
+			Type:      jobType,
+			Status:    JobRunning,
+			StartedAt: time.Now().Format(time.RFC3339),
+		},
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	m.prune(time.Now())
+	m.jobs[job.info.ID] = job
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		result, err := fn(ctx, job)
+
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.finishedAt = time.Now()
+		job.info.FinishedAt = job.finishedAt.Format(time.RFC3339)
+		job.info.Result = result
+		switch {
+		case ctx.Err() == context.Canceled:
+			job.info.Status = JobCancelled
+		case err != nil:
+			job.info.Status = JobFailed
+			job.info.Error = err.Error()
+		default:
+			job.info.Status = JobCompleted
+		}
+	}()
+
+	return job.snapshot()
+}
+
+// Get returns a snapshot of the job with the given ID
+func (m *JobManager) Get(id string) (JobInfo, bool) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return JobInfo{}, false
+	}
+	return job.snapshot(), true
+}
+
+// List returns snapshots of all known jobs, newest first
+func (m *JobManager) List() []JobInfo {
+	m.mu.Lock()
+	m.prune(time.Now())
+	jobs := make([]JobInfo, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job.snapshot())
+	}
+	m.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt > jobs[j].StartedAt
+	})
+	return jobs
+}
+
+// Cancel stops a running job
+func (m *JobManager) Cancel(id string) error {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("job %s not found", id)
+	}
+
+	if job.snapshot().Status != JobRunning {
+		return fmt.Errorf("job %s is not running", id)
+	}
+
+	job.cancel()
+	return nil
+}
+
+// prune drops finished jobs past their TTL and the oldest finished jobs beyond the cap.
+// Callers hold m.mu.
+func (m *JobManager) prune(now time.Time) {
+	type finishedJob struct {
+		id         string
+		finishedAt time.Time
+	}
+	var finished []finishedJob
+	for id, job := range m.jobs {
+		job.mu.Lock()
+		finishedAt := job.finishedAt
+		job.mu.Unlock()
+		if finishedAt.IsZero() {
+			continue
+		}
+		if now.Sub(finishedAt) > finishedJobTTL {
+			delete(m.jobs, id)
+			continue
+		}
+		finished = append(finished, finishedJob{id, finishedAt})
+	}
+
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].finishedAt.Before(finished[j].finishedAt)
+	})
+	for _, job := range finished[:len(finished)-maxFinishedJobs] {
+		delete(m.jobs, job.id)
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Parquet files end with the thrift-encoded FileMetaData, its 4-byte little-endian
+// length and the "PAR1" magic. Only the parts needed to show the schema are decoded.
+
+const parquetMagic = "PAR1"
+
+var (
+	parquetTypes       = []string{"BOOLEAN", "INT32", "INT64", "INT96", "FLOAT", "DOUBLE", "BYTE_ARRAY", "FIXED_LEN_BYTE_ARRAY"}
+	parquetRepetitions = []string{"REQUIRED", "OPTIONAL", "REPEATED"}
+	parquetConverted   = []string{"UTF8", "MAP", "MAP_KEY_VALUE", "LIST", "ENUM", "DECIMAL", "DATE", "TIME_MILLIS", "TIME_MICROS",
+		"TIMESTAMP_MILLIS", "TIMESTAMP_MICROS", "UINT_8", "UINT_16", "UINT_32", "UINT_64", "INT_8", "INT_16", "INT_32", "INT_64",
+		"JSON", "BSON", "INTERVAL"}
+)
+
+// ParquetColumn is one leaf column of a Parquet schema
+type ParquetColumn struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Repetition    string `json:"repetition"`
+	ConvertedType string `json:"converted_type,omitempty"`
+}
+
+// ParquetSchema is the schema and row count read from a Parquet footer
+type ParquetSchema struct {
+	NumRows   int64           `json:"num_rows"`
+	CreatedBy string          `json:"created_by,omitempty"`
+	Columns   []ParquetColumn `json:"columns"`
+}
+
+type parquetSchemaElement struct {
+	name          string
+	typ           int32
+	hasType       bool
+	repetition    int32
+	numChildren   int32
+	convertedType int32
+	hasConverted  bool
+}
+
+// parquetFooterLength validates the 8-byte trailer of a Parquet file and returns the metadata length
+func parquetFooterLength(trailer []byte) (int64, error) {
+	if len(trailer) != 8 || string(trailer[4:]) != parquetMagic {
+		return 0, fmt.Errorf("not a parquet file")
+	}
+	return int64(binary.LittleEndian.Uint32(trailer[:4])), nil
+}
+
+// parseParquetSchema decodes the schema and row count from thrift-encoded FileMetaData
+func parseParquetSchema(footer []byte) (*ParquetSchema, error) {
+	r := &thriftReader{buf: footer}
+	schema := &ParquetSchema{Columns: []ParquetColumn{}}
+	var elements []parquetSchemaElement
+
+	err := r.readStruct(func(id int16, fieldType byte) error {
+		switch {
+		case id == 2 && fieldType == thriftList:
+			elemType, size, err := r.readListHeader()
+			if err != nil {
+				return err
+			}
+			for i := 0; i < size; i++ {
+				if elemType != thriftStruct {
+					return fmt.Errorf("unexpected schema element type %d", elemType)
+				}
+				element, err := readParquetSchemaElement(r)
+				if err != nil {
+					return err
+				}
+				elements = append(elements, element)
+			}
+			return nil
+		case id == 3 && fieldType == thriftI64:
+			v, err := r.readVarint()
+			schema.NumRows = v
+			return err
+		case id == 6 && fieldType == thriftBinary:
+			v, err := r.readBinary()
+			schema.CreatedBy = string(v)
+			return err
+		}
+		return r.skip(fieldType)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid parquet footer: %w", err)
+	}
+
+	// The first element is the root; walk the tree and emit leaves with their dotted path
+	if len(elements) > 0 {
+		index := 1
+		var walk func(prefix []string, children int32)
+		walk = func(prefix []string, children int32) {
+			for c := int32(0); c < children && index < len(elements); c++ {
+				element := elements[index]
+				index++
+				path := append(append([]string(nil), prefix...), element.name)
+				if element.numChildren > 0 {
+					walk(path, element.numChildren)
+					continue
+				}
+				column := ParquetColumn{
+					Name:       strings.Join(path, "."),
+					Type:       enumName(parquetTypes, element.typ, element.hasType),
+					Repetition: enumName(parquetRepetitions, element.repetition, true),
+				}
+				if element.hasConverted {
+					column.ConvertedType = enumName(parquetConverted, element.convertedType, true)
+				}
+				schema.Columns = append(schema.Columns, column)
+			}
+		}
+		walk(nil, elements[0].numChildren)
+	}
+
+	return schema, nil
+}
+
+func readParquetSchemaElement(r *thriftReader) (parquetSchemaElement, error) {
+	var element parquetSchemaElement
+	err := r.readStruct(func(id int16, fieldType byte) error {
+		if fieldType == thriftI32 && (id == 1 || id == 3 || id == 5 || id == 6) {
+			v, err := r.readVarint()
+			switch id {
+			case 1:
+				element.typ, element.hasType = int32(v), true
+			case 3:
+				element.repetition = int32(v)
+			case 5:
+				element.numChildren = int32(v)
+			case 6:
+				element.convertedType, element.hasConverted = int32(v), true
+			}
+			return err
+		}
+		if id == 4 && fieldType == thriftBinary {
+			v, err := r.readBinary()
+			element.name = string(v)
+			return err
+		}
+		return r.skip(fieldType)
+	})
+	return element, err
+}
+
+func enumName(names []string, value int32, present bool) string {
+	if !present {
+		return ""
+	}
+	if value >= 0 && int(value) < len(names) {
+		return names[value]
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", value)
+}
+
+// Thrift compact protocol type IDs
+const (
+	thriftStop      = 0
+	thriftTrue      = 1
+	thriftFalse     = 2
+	thriftByte      = 3
+	thriftI16       = 4
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftDouble    = 7
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftSet       = 10
+	thriftMap       = 11
+	thriftStruct    = 12
+	thriftMaxDepth  = 64
+	thriftMaxLength = 1 << 24
+)
+
+// thriftReader decodes the subset of the thrift compact protocol used by Parquet metadata
+type thriftReader struct {
+	buf   []byte
+	pos   int
+	depth int
+}
+
+func (r *thriftReader) readByte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, fmt.Errorf("unexpected end of data")
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *thriftReader) readUvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint")
+	}
+	r.pos += n
+	return v, nil
+}
+
+// readVarint reads a zigzag-encoded integer
+func (r *thriftReader) readVarint() (int64, error) {
+	v, err := r.readUvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (r *thriftReader) readBinary() ([]byte, error) {
+	length, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if length > thriftMaxLength || r.pos+int(length) > len(r.buf) {
+		return nil, fmt.Errorf("binary field out of bounds")
+	}
+	v := r.buf[r.pos : r.pos+int(length)]
+	r.pos += int(length)
+	return v, nil
+}
+
+func (r *thriftReader) readListHeader() (byte, int, error) {
+	header, err := r.readByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	size := int(header >> 4)
+	if size == 15 {
+		v, err := r.readUvarint()
+		if err != nil {
+			return 0, 0, err
+		}
+		if v > thriftMaxLength {
+			return 0, 0, fmt.Errorf("list too long")
+		}
+		size = int(v)
+	}
+	return header & 0x0f, size, nil
+}
+
+// enter tracks the nesting of structs and containers; the caller must decrement depth when done
+func (r *thriftReader) enter() error {
+	r.depth++
+	if r.depth > thriftMaxDepth {
+		return fmt.Errorf("structure nested too deeply")
+	}
+	return nil
+}
+
+// readStruct calls field for every field of a struct until its stop marker
+func (r *thriftReader) readStruct(field func(id int16, fieldType byte) error) error {
+	defer func() { r.depth-- }()
+	if err := r.enter(); err != nil {
+		return err
+	}
+
+	var lastID int16
+	for {
+		header, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		fieldType := header & 0x0f
+		if fieldType == thriftStop {
+			return nil
+		}
+
+		if delta := int16(header >> 4); delta != 0 {
+			lastID += delta
+		} else {
+			id, err := r.readVarint()
+			if err != nil {
+				return err
+			}
+			lastID = int16(id)
+		}
+
+		if err := field(lastID, fieldType); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *thriftReader) skip(fieldType byte) error {
+	switch fieldType {
+	case thriftTrue, thriftFalse:
+		return nil
+	case thriftByte:
+		_, err := r.readByte()
+		return err
+	case thriftI16, thriftI32, thriftI64:
+		_, err := r.readUvarint()
+		return err
+	case thriftDouble:
+		if r.pos+8 > len(r.buf) {
+			return fmt.Errorf("unexpected end of data")
+		}
+		r.pos += 8
+		return nil
+	case thriftBinary:
+		_, err := r.readBinary()
+		return err
+	case thriftList, thriftSet:
+		defer func() { r.depth-- }()
+		if err := r.enter(); err != nil {
+			return err
+		}
+		elemType, size, err := r.readListHeader()
+		if err != nil {
+			return err
+		}
+		elemType = containerElemType(elemType)
+		for i := 0; i < size; i++ {
+			if err := r.skip(elemType); err != nil {
+				return err
+			}
+		}
+		return nil
+	case thriftMap:
+		defer func() { r.depth-- }()
+		if err := r.enter(); err != nil {
+			return err
+		}
+		size, err := r.readUvarint()
+		if err != nil || size == 0 {
+			return err
+		}
+		if size > thriftMaxLength {
+			return fmt.Errorf("map too large")
+		}
+		types, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		keyType, valueType := containerElemType(types>>4), containerElemType(types&0x0f)
+		for i := uint64(0); i < size; i++ {
+			if err := r.skip(keyType); err != nil {
+				return err
+			}
+			if err := r.skip(valueType); err != nil {
+				return err
+			}
+		}
+		return nil
+	case thriftStruct:
+		return r.readStruct(func(_ int16, fieldType byte) error {
+			return r.skip(fieldType)
+		})
+	}
+	return fmt.Errorf("unknown thrift type %d", fieldType)
+}
+
+// containerElemType maps the element type of a list, set or map to the type to skip;
+// booleans inside containers take a whole byte
+func containerElemType(elemType byte) byte {
+	if elemType == thriftTrue || elemType == thriftFalse {
+		return thriftByte
+	}
+	return elemType
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// validParquetFooter is the thrift compact encoding of a FileMetaData with a root and
+// two columns, id (required INT64) and name (optional UTF8 BYTE_ARRAY), 100 rows and a created_by
+var validParquetFooter = []byte{
+	0x15, 0x02, // 1: version = 1
+	0x19, 0x3c, // 2: schema, list of 3 structs
+	0x48, 0x06, 's', 'c', 'h', 'e', 'm', 'a', // root name
+	0x15, 0x04, // root num_children = 2
+	0x00,
+	0x15, 0x04, // type = INT64
+	0x25, 0x00, // repetition = REQUIRED
+	0x18, 0x02, 'i', 'd',
+	0x00,
+	0x15, 0x0c, // type = BYTE_ARRAY
+	0x25, 0x02, // repetition = OPTIONAL
+	0x18, 0x04, 'n', 'a', 'm', 'e',
+	0x25, 0x00, // converted_type = UTF8
+	0x00,
+	0x16, 0xc8, 0x01, // 3: num_rows = 100
+	0x38, 0x04, 't', 'e', 's', 't', // 6: created_by
+	0x00,
+}
+
+func TestParseParquetSchema(t *testing.T) {
+	schema, err := parseParquetSchema(validParquetFooter)
+	if err != nil {
+		t.Fatalf("parseParquetSchema returned %v", err)
+	}
+
+	want := &ParquetSchema{
+		NumRows:   100,
+		CreatedBy: "test",
+		Columns: []ParquetColumn{
+			{Name: "id", Type: "INT64", Repetition: "REQUIRED"},
+			{Name: "name", Type: "BYTE_ARRAY", Repetition: "OPTIONAL", ConvertedType: "UTF8"},
+		},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("parseParquetSchema = %+v, want %+v", schema, want)
+	}
+}
+
+func TestParseParquetSchemaTruncated(t *testing.T) {
+	for length := 0; length < len(validParquetFooter); length++ {
+		if _, err := parseParquetSchema(validParquetFooter[:length]); err == nil {
+			t.Errorf("parseParquetSchema of the first %d bytes succeeded, want an error", length)
+		}
+	}
+}
+
+func TestParseParquetSchemaMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		footer []byte
+	}{
+		{name: "unknown field type", footer: []byte{0x1d, 0x00}},
+		{name: "binary longer than the footer", footer: []byte{0x68, 0x10, 'a'}},
+		{name: "binary over the length cap", footer: []byte{0x68, 0xff, 0xff, 0xff, 0xff, 0x0f}},
+		{name: "list over the length cap", footer: []byte{0x19, 0xfc, 0xff, 0xff, 0xff, 0xff, 0x0f}},
+		{name: "schema list of integers", footer: []byte{0x29, 0x15, 0x02, 0x00}},
+		{name: "schema list longer than the footer", footer: []byte{0x29, 0xfc, 0x10, 0x00}},
+		{name: "invalid varint", footer: append([]byte{0x16}, bytes.Repeat([]byte{0xff}, 11)...)},
+		{name: "double past the end", footer: []byte{0x17, 0x00, 0x00}},
+		{name: "map over the length cap", footer: []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0x0f, 0x55}},
+		{name: "map of booleans past the end", footer: []byte{0x1b, 0x80, 0x80, 0x04, 0x11}},
+		{name: "structs nested too deeply", footer: bytes.Repeat([]byte{0x1c}, 1000)},
+		{name: "lists nested too deeply", footer: append([]byte{0x19}, bytes.Repeat([]byte{0x19}, 100000)...)},
+		{name: "maps nested too deeply", footer: append([]byte{0x1b}, bytes.Repeat([]byte{0x01, 0xbb}, 100000)...)},
+		{name: "missing stop marker", footer: []byte{0x15, 0x02}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if schema, err := parseParquetSchema(tt.footer); err == nil {
+				t.Errorf("parseParquetSchema = %+v, want an error", schema)
+			}
+		})
+	}
+}
+
+func TestParseParquetSchemaChildrenPastEnd(t *testing.T) {
+	// A root claiming more children than there are elements lists only the ones present
+	footer := []byte{
+		0x29, 0x2c, // 2: schema, list of 2 structs
+		0x48, 0x01, 'r', 0x15, 0x14, 0x00, // root with 10 children
+		0x15, 0x02, 0x38, 0x01, 'a', 0x00, // INT32 column a
+		0x00,
+	}
+	schema, err := parseParquetSchema(footer)
+	if err != nil {
+		t.Fatalf("parseParquetSchema returned %v", err)
+	}
+	if len(schema.Columns) != 1 || schema.Columns[0].Name != "a" || schema.Columns[0].Type != "INT32" {
+		t.Errorf("columns = %+v, want the single column a", schema.Columns)
+	}
+}
+
+func TestParquetFooterLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		trailer []byte
+		want    int64
+		wantErr bool
+	}{
+		{name: "valid", trailer: []byte{0x2a, 0x01, 0x00, 0x00, 'P', 'A', 'R', '1'}, want: 298},
+		{name: "maximum length", trailer: []byte{0xff, 0xff, 0xff, 0xff, 'P', 'A', 'R', '1'}, want: 1<<32 - 1},
+		{name: "wrong magic", trailer: []byte{0x2a, 0x00, 0x00, 0x00, 'P', 'A', 'R', '2'}, wantErr: true},
+		{name: "too short", trailer: []byte{'P', 'A', 'R', '1'}, wantErr: true},
+		{name: "empty", trailer: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parquetFooterLength(tt.trailer)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parquetFooterLength = %d, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parquetFooterLength = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
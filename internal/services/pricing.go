@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+)
+
+const (
+	// priceTTL is how long on-demand prices are cached; AWS changes them rarely
+	priceTTL = 24 * time.Hour
+	// HoursPerMonth is the average number of hours in a month used for monthly estimates
+	HoursPerMonth = 730
+)
+
+// PricingService looks up EC2 on-demand prices from the AWS Price List API
+type PricingService struct {
+	Client *pricing.Client
+
+	prices *ttlCache[float64]
+}
+
+// NewPricingService initializes the PricingService
+func NewPricingService(client *pricing.Client) *PricingService {
+	return &PricingService{
+		Client: client,
+		prices: newTTLCache[float64](priceTTL),
+	}
+}
+
+type priceListItem struct {
+	Terms struct {
+		OnDemand map[string]struct {
+			PriceDimensions map[string]struct {
+				Unit         string            `json:"unit"`
+				PricePerUnit map[string]string `json:"pricePerUnit"`
+			} `json:"priceDimensions"`
+		} `json:"OnDemand"`
+	} `json:"terms"`
+}
+
+// OnDemandHourlyPrice returns the hourly USD price of a shared-tenancy Linux instance.
+// A price of 0 means the instance type is not sold in the region.
+func (s *PricingService) OnDemandHourlyPrice(ctx context.Context, region, instanceType string) (float64, error) {
+	key := region + "/" + instanceType
+	if price, ok := s.prices.Get(key); ok {
+		return price, nil
+	}
+
+	filter := func(field, value string) types.Filter {
+		return types.Filter{Type: types.FilterTypeTermMatch, Field: aws.String(field), Value: aws.String(value)}
+	}
+	output, err := s.Client.GetProducts(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonEC2"),
+		Filters: []types.Filter{
+			filter("regionCode", region),
+			filter("instanceType", instanceType),
+			filter("operatingSystem", "Linux"),
+			filter("tenancy", "Shared"),
+			filter("preInstalledSw", "NA"),
+			filter("capacitystatus", "Used"),
+			filter("licenseModel", "No License required"),
+		},
+		MaxResults: aws.Int32(10),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to get price of %s: %w", instanceType, err)
+	}
+
+	var price float64
+	for _, raw := range output.PriceList {
+		var item priceListItem
+		if err := json.Unmarshal([]byte(raw), &item); err != nil {
+			continue
+		}
+		for _, term := range item.Terms.OnDemand {
+			for _, dimension := range term.PriceDimensions {
+				if dimension.Unit != "Hrs" {
+					continue
+				}
+				if value, err := strconv.ParseFloat(dimension.PricePerUnit["USD"], 64); err == nil && value > 0 {
+					price = value
+				}
+			}
+		}
+		if price > 0 {
+			break
+		}
+	}
+
+	s.prices.Set(key, price)
+	return price, nil
+}
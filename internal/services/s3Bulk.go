@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// deleteObjectsBatchSize is the maximum number of keys DeleteObjects accepts per call
+	deleteObjectsBatchSize = 1000
+	// maxSingleCopySize is the largest object CopyObject can copy in one request
+	maxSingleCopySize = 5 * 1024 * 1024 * 1024
+	// multipartCopyPartSize is the smallest part size used when copying larger objects
+	multipartCopyPartSize = 512 * 1024 * 1024
+	// maxMultipartParts is the most parts a multipart upload may have
+	maxMultipartParts   = 10000
+	bulkCopyConcurrency = 8
+)
+
+// BulkObjectsInput selects objects either by explicit keys or by prefix.
+// DestinationBucket and DestinationPrefix are only used for copies.
+type BulkObjectsInput struct {
+	Bucket            string   `json:"bucket"`
+	Keys              []string `json:"keys"`
+	Prefix            string   `json:"prefix"`
+	DestinationBucket string   `json:"destination_bucket,omitempty"`
+	DestinationPrefix string   `json:"destination_prefix,omitempty"`
+}
+
+// BulkObjectResult is the outcome for a single key of a bulk operation
+type BulkObjectResult struct {
+	Key            string `json:"key"`
+	DestinationKey string `json:"destination_key,omitempty"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+}
+
+// BulkReport summarises a bulk operation with a result per key
+type BulkReport struct {
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []BulkObjectResult `json:"results"`
+}
+
+func (r *BulkReport) add(result BulkObjectResult) {
+	if result.Error != "" {
+		result.Status = "failed"
+		r.Failed++
+	} else {
+		r.Succeeded++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// ParseKeyManifest reads object keys from a CSV manifest. Rows hold either a single
+// key column, taken as is, or "bucket,key[,...]" as in S3 Inventory and Batch Operations
+// manifests, whose keys are URL-encoded. A header row naming the columns is skipped.
+func ParseKeyManifest(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var keys []string
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		if line == 1 && isManifestHeader(record) {
+			continue
+		}
+
+		switch {
+		case len(record) == 1 && record[0] != "":
+			keys = append(keys, record[0])
+		case len(record) >= 2 && record[1] != "":
+			key, err := url.QueryUnescape(record[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid manifest: line %d: key is not URL-encoded: %w", line, err)
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("manifest contains no keys")
+	}
+	return keys, nil
+}
+
+// isManifestHeader reports whether a manifest row names its columns rather than an object
+func isManifestHeader(record []string) bool {
+	switch len(record) {
+	case 0:
+		return false
+	case 1:
+		return strings.EqualFold(strings.TrimSpace(record[0]), "key")
+	}
+	return strings.EqualFold(strings.TrimSpace(record[0]), "bucket") && strings.EqualFold(strings.TrimSpace(record[1]), "key")
+}
+
+// BulkDelete deletes the selected objects in batches of up to 1000 keys
+func (s *S3Service) BulkDelete(input BulkObjectsInput) (*BulkReport, error) {
+	if err := validateBulkInput(input, false); err != nil {
+		return nil, err
+	}
+
+	client, err := s.clientForBucket(input.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	keys, err := resolveBulkKeys(ctx, client, input)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BulkReport{Results: []BulkObjectResult{}}
+	for start := 0; start < len(keys); start += deleteObjectsBatchSize {
+		end := min(start+deleteObjectsBatchSize, len(keys))
+
+		var objects []types.ObjectIdentifier
+		for _, key := range keys[start:end] {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		output, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(input.Bucket),
+			Delete: &types.Delete{Objects: objects},
+		})
+		if err != nil {
+			for _, key := range keys[start:end] {
+				report.add(BulkObjectResult{Key: key, Error: err.Error()})
+			}
+			continue
+		}
+
+		for _, deleted := range output.Deleted {
+			report.add(BulkObjectResult{Key: aws.ToString(deleted.Key), Status: "deleted"})
+		}
+		for _, deleteErr := range output.Errors {
+			report.add(BulkObjectResult{
+				Key:   aws.ToString(deleteErr.Key),
+				Error: fmt.Sprintf("%s: %s", aws.ToString(deleteErr.Code), aws.ToString(deleteErr.Message)),
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// BulkCopy copies the selected objects server-side to the destination bucket and prefix.
+// Objects selected by prefix keep their path relative to that prefix.
+func (s *S3Service) BulkCopy(input BulkObjectsInput) (*BulkReport, error) {
+	if err := validateBulkInput(input, true); err != nil {
+		return nil, err
+	}
+
+	sourceClient, err := s.clientForBucket(input.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	destinationClient, err := s.clientForBucket(input.DestinationBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	keys, err := resolveBulkKeys(ctx, sourceClient, input)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BulkReport{Results: []BulkObjectResult{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	work := make(chan string)
+
+	for i := 0; i < bulkCopyConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range work {
+				destinationKey := input.DestinationPrefix + strings.TrimPrefix(key, input.Prefix)
+				err := copyObject(ctx, sourceClient, destinationClient, input.Bucket, key, input.DestinationBucket, destinationKey, -1)
+
+				result := BulkObjectResult{Key: key, DestinationKey: destinationKey, Status: "copied"}
+				if err != nil {
+					result.Error = err.Error()
+				}
+
+				mu.Lock()
+				report.add(result)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, key := range keys {
+		work <- key
+	}
+	close(work)
+	wg.Wait()
+
+	sort.Slice(report.Results, func(i, j int) bool {
+		return report.Results[i].Key < report.Results[j].Key
+	})
+	return report, nil
+}
+
+func validateBulkInput(input BulkObjectsInput, isCopy bool) error {
+	var problems []string
+	if input.Bucket == "" {
+		problems = append(problems, "bucket is required")
+	}
+	if len(input.Keys) == 0 && input.Prefix == "" {
+		problems = append(problems, "either keys or a prefix is required")
+	}
+	if isCopy && input.DestinationBucket == "" {
+		problems = append(problems, "destination_bucket is required")
+	}
+	if isCopy && input.Bucket == input.DestinationBucket && input.DestinationPrefix == input.Prefix && len(input.Keys) == 0 {
+		problems = append(problems, "destination must differ from the source")
+	}
+	return validationError(problems)
+}
+
+// resolveBulkKeys returns the explicit keys, or every key under the prefix
+func resolveBulkKeys(ctx context.Context, client *s3.Client, input BulkObjectsInput) ([]string, error) {
+	if len(input.Keys) > 0 {
+		return input.Keys, nil
+	}
+
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(input.Bucket),
+		Prefix: aws.String(input.Prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, object := range page.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+	}
+	return keys, nil
+}
+
+// copyObject copies an object server-side, switching to a multipart copy for objects
+// larger than 5GB. Pass a negative size to have it looked up first. The source is read with
+// sourceClient, which must be for the source bucket's region, and the copy is made with client.
+func copyObject(ctx context.Context, sourceClient, client *s3.Client, sourceBucket, sourceKey, destinationBucket, destinationKey string, size int64) error {
+	var head *s3.HeadObjectOutput
+	if size < 0 {
+		var err error
+		if head, err = headSourceObject(ctx, sourceClient, sourceBucket, sourceKey, ""); err != nil {
+			return err
+		}
+		size = aws.ToInt64(head.ContentLength)
+	}
+
+	if size <= maxSingleCopySize {
+		_, err := client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:     aws.String(destinationBucket),
+			Key:        aws.String(destinationKey),
+			CopySource: aws.String(copySource(sourceBucket, sourceKey, "")),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to copy object: %w", err)
+		}
+		return nil
+	}
+
+	if head == nil {
+		var err error
+		if head, err = headSourceObject(ctx, sourceClient, sourceBucket, sourceKey, ""); err != nil {
+			return err
+		}
+	}
+	_, err := multipartCopyObject(ctx, client, sourceBucket, sourceKey, "", destinationBucket, destinationKey, head)
+	return err
+}
+
+func headSourceObject(ctx context.Context, client *s3.Client, bucket, key, versionID string) (*s3.HeadObjectOutput, error) {
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	head, err := client.HeadObject(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source object: %w", err)
+	}
+	return head, nil
+}
+
+// multipartCopyObject copies an object part by part. Unlike CopyObject, a multipart upload does
+// not inherit anything from the source, so its headers, metadata, storage class and encryption
+// are taken from the source head. It returns the version ID of the new object.
+func multipartCopyObject(ctx context.Context, client *s3.Client, sourceBucket, sourceKey, sourceVersionID, destinationBucket, destinationKey string, head *s3.HeadObjectOutput) (string, error) {
+	size := aws.ToInt64(head.ContentLength)
+	source := copySource(sourceBucket, sourceKey, sourceVersionID)
+
+	upload, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(destinationBucket),
+		Key:                  aws.String(destinationKey),
+		ContentType:          head.ContentType,
+		CacheControl:         head.CacheControl,
+		ContentEncoding:      head.ContentEncoding,
+		ContentDisposition:   head.ContentDisposition,
+		ContentLanguage:      head.ContentLanguage,
+		Metadata:             head.Metadata,
+		StorageClass:         head.StorageClass,
+		ServerSideEncryption: head.ServerSideEncryption,
+		SSEKMSKeyId:          head.SSEKMSKeyId,
+		BucketKeyEnabled:     head.BucketKeyEnabled,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start multipart copy: %w", err)
+	}
+
+	abort := func(cause error) error {
+		_, abortErr := client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(destinationBucket),
+			Key:      aws.String(destinationKey),
+			UploadId: upload.UploadId,
+		})
+		return errors.Join(cause, abortErr)
+	}
+
+	// Grow the parts for objects that would otherwise need more than the 10000 S3 allows
+	partSize := max(int64(multipartCopyPartSize), (size+maxMultipartParts-1)/maxMultipartParts)
+
+	var parts []types.CompletedPart
+	for partNumber, offset := int32(1), int64(0); offset < size; partNumber, offset = partNumber+1, offset+partSize {
+		last := min(offset+partSize, size) - 1
+
+		output, err := client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:          aws.String(destinationBucket),
+			Key:             aws.String(destinationKey),
+			UploadId:        upload.UploadId,
+			PartNumber:      aws.Int32(partNumber),
+			CopySource:      aws.String(source),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", offset, last)),
+		})
+		if err != nil {
+			return "", abort(fmt.Errorf("failed to copy part %d: %w", partNumber, err))
+		}
+
+		parts = append(parts, types.CompletedPart{
+			ETag:       output.CopyPartResult.ETag,
+			PartNumber: aws.Int32(partNumber),
+		})
+	}
+
+	completed, err := client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(destinationBucket),
+		Key:             aws.String(destinationKey),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return "", abort(fmt.Errorf("failed to complete multipart copy: %w", err))
+	}
+	return aws.ToString(completed.VersionId), nil
+}
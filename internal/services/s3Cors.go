@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxCORSRules is the most rules S3 accepts in a CORS configuration
+const maxCORSRules = 100
+
+var corsMethods = map[string]bool{"GET": true, "PUT": true, "POST": true, "DELETE": true, "HEAD": true}
+
+// CORSRule is a typed view of one bucket CORS rule
+type CORSRule struct {
+	ID             string   `json:"id,omitempty"`
+	AllowedOrigins []string `json:"allowed_origins"`
+	AllowedMethods []string `json:"allowed_methods"`
+	AllowedHeaders []string `json:"allowed_headers,omitempty"`
+	ExposeHeaders  []string `json:"expose_headers,omitempty"`
+	MaxAgeSeconds  int32    `json:"max_age_seconds,omitempty"`
+}
+
+// CORSConfiguration is the full set of CORS rules of a bucket
+type CORSConfiguration struct {
+	Rules []CORSRule `json:"rules"`
+}
+
+// GetBucketCORS reads the CORS rules of a bucket
+func (s *S3Service) GetBucketCORS(bucketName string) (*CORSConfiguration, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.GetBucketCors(context.TODO(), &s3.GetBucketCorsInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		if isAPIError(err, "NoSuchCORSConfiguration") {
+			return &CORSConfiguration{Rules: []CORSRule{}}, nil
+		}
+		return nil, fmt.Errorf("failed to get CORS configuration: %w", err)
+	}
+
+	config := &CORSConfiguration{Rules: []CORSRule{}}
+	for _, rule := range output.CORSRules {
+		config.Rules = append(config.Rules, CORSRule{
+			ID:             aws.ToString(rule.ID),
+			AllowedOrigins: rule.AllowedOrigins,
+			AllowedMethods: rule.AllowedMethods,
+			AllowedHeaders: rule.AllowedHeaders,
+			ExposeHeaders:  rule.ExposeHeaders,
+			MaxAgeSeconds:  aws.ToInt32(rule.MaxAgeSeconds),
+		})
+	}
+	return config, nil
+}
+
+// PreviewBucketCORS validates proposed CORS rules and diffs them against the current ones
+func (s *S3Service) PreviewBucketCORS(bucketName string, proposed CORSConfiguration) (*PolicyChangePreview, error) {
+	problems := ValidateCORSConfiguration(proposed)
+	preview := &PolicyChangePreview{Valid: len(problems) == 0, Problems: problems}
+	if !preview.Valid {
+		return preview, nil
+	}
+
+	current, err := s.GetBucketCORS(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.Diff = diffEntries(corsDiffEntries(*current), corsDiffEntries(proposed))
+	return preview, nil
+}
+
+// PutBucketCORS validates and replaces the CORS rules of a bucket, returning the applied diff
+func (s *S3Service) PutBucketCORS(bucketName string, config CORSConfiguration) (*DocumentDiff, error) {
+	if len(config.Rules) == 0 {
+		return nil, validationError([]string{"at least one rule is required; delete the configuration instead"})
+	}
+
+	preview, err := s.PreviewBucketCORS(bucketName, config)
+	if err != nil {
+		return nil, err
+	}
+	if !preview.Valid {
+		return nil, validationError(preview.Problems)
+	}
+
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []types.CORSRule
+	for _, rule := range config.Rules {
+		sdkRule := types.CORSRule{
+			AllowedOrigins: rule.AllowedOrigins,
+			AllowedMethods: rule.AllowedMethods,
+			AllowedHeaders: rule.AllowedHeaders,
+			ExposeHeaders:  rule.ExposeHeaders,
+		}
+		if rule.ID != "" {
+			sdkRule.ID = aws.String(rule.ID)
+		}
+		if rule.MaxAgeSeconds > 0 {
+			sdkRule.MaxAgeSeconds = aws.Int32(rule.MaxAgeSeconds)
+		}
+		rules = append(rules, sdkRule)
+	}
+
+	_, err = client.PutBucketCors(context.TODO(), &s3.PutBucketCorsInput{
+		Bucket:            aws.String(bucketName),
+		CORSConfiguration: &types.CORSConfiguration{CORSRules: rules},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put CORS configuration: %w", err)
+	}
+
+	return preview.Diff, nil
+}
+
+// DeleteBucketCORS removes the CORS configuration of a bucket
+func (s *S3Service) DeleteBucketCORS(bucketName string) error {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteBucketCors(context.TODO(), &s3.DeleteBucketCorsInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete CORS configuration: %w", err)
+	}
+	return nil
+}
+
+// ValidateCORSConfiguration returns every problem found in the CORS rules
+func ValidateCORSConfiguration(config CORSConfiguration) []string {
+	var problems []string
+	if len(config.Rules) > maxCORSRules {
+		problems = append(problems, fmt.Sprintf("at most %d rules are allowed", maxCORSRules))
+	}
+
+	ids := make(map[string]bool)
+	for i, rule := range config.Rules {
+		name := fmt.Sprintf("rule %d", i+1)
+		if rule.ID != "" {
+			name = fmt.Sprintf("rule %q", rule.ID)
+			if ids[rule.ID] {
+				problems = append(problems, name+": id is used more than once")
+			}
+			ids[rule.ID] = true
+		}
+
+		if len(rule.AllowedOrigins) == 0 {
+			problems = append(problems, name+": at least one allowed origin is required")
+		}
+		for _, origin := range rule.AllowedOrigins {
+			if strings.Count(origin, "*") > 1 {
+				problems = append(problems, fmt.Sprintf("%s: origin %q may contain at most one wildcard", name, origin))
+			}
+		}
+
+		if len(rule.AllowedMethods) == 0 {
+			problems = append(problems, name+": at least one allowed method is required")
+		}
+		for _, method := range rule.AllowedMethods {
+			if !corsMethods[method] {
+				problems = append(problems, fmt.Sprintf("%s: unsupported method %q", name, method))
+			}
+		}
+
+		for _, header := range rule.AllowedHeaders {
+			if strings.Count(header, "*") > 1 {
+				problems = append(problems, fmt.Sprintf("%s: header %q may contain at most one wildcard", name, header))
+			}
+		}
+		for _, header := range rule.ExposeHeaders {
+			if strings.Contains(header, "*") {
+				problems = append(problems, fmt.Sprintf("%s: expose header %q must not contain wildcards", name, header))
+			}
+		}
+
+		if rule.MaxAgeSeconds < 0 {
+			problems = append(problems, name+": max_age_seconds must not be negative")
+		}
+	}
+
+	return problems
+}
+
+// corsDiffEntries flattens CORS rules for diffing. Rules are matched by ID when set,
+// otherwise by their allowed origins.
+func corsDiffEntries(config CORSConfiguration) []DiffEntry {
+	var entries []DiffEntry
+	for _, rule := range config.Rules {
+		id := rule.ID
+		if id == "" {
+			origins := append([]string(nil), rule.AllowedOrigins...)
+			sort.Strings(origins)
+			id = "origins:" + strings.Join(origins, ",")
+		}
+
+		fields := map[string][]string{
+			"AllowedOrigins": rule.AllowedOrigins,
+			"AllowedMethods": rule.AllowedMethods,
+			"AllowedHeaders": rule.AllowedHeaders,
+			"ExposeHeaders":  rule.ExposeHeaders,
+		}
+		if rule.MaxAgeSeconds > 0 {
+			fields["MaxAgeSeconds"] = []string{strconv.Itoa(int(rule.MaxAgeSeconds))}
+		}
+		entries = append(entries, DiffEntry{ID: id, Fields: fields})
+	}
+	return entries
+}
@@ -0,0 +1,609 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	// lifecycleDryRunMaxObjects caps how many objects a dry run inspects
+	lifecycleDryRunMaxObjects = 10000
+	// lifecycleDryRunSampleSize caps how many matched objects a dry run returns
+	lifecycleDryRunSampleSize = 100
+)
+
+// lifecycleDateLayout is the format of lifecycle dates; S3 applies them at midnight UTC
+const lifecycleDateLayout = "2006-01-02"
+
+// LifecycleTransition moves objects to another storage class after a number of days,
+// or on a date when Date is set
+type LifecycleTransition struct {
+	Days         int32  `json:"days"`
+	Date         string `json:"date,omitempty"`
+	StorageClass string `json:"storage_class"`
+}
+
+// NoncurrentVersionTransition moves versions to another storage class a number of days
+// after they become noncurrent, optionally keeping the newest ones where they are
+type NoncurrentVersionTransition struct {
+	NoncurrentDays          int32  `json:"noncurrent_days"`
+	StorageClass            string `json:"storage_class"`
+	NewerNoncurrentVersions int32  `json:"newer_noncurrent_versions,omitempty"`
+}
+
+// LifecycleRule is a typed view of one bucket lifecycle rule
+type LifecycleRule struct {
+	ID                            string                        `json:"id"`
+	Enabled                       bool                          `json:"enabled"`
+	Prefix                        string                        `json:"prefix,omitempty"`
+	Tags                          map[string]string             `json:"tags,omitempty"`
+	ObjectSizeGreaterThan         int64                         `json:"object_size_greater_than,omitempty"`
+	ObjectSizeLessThan            int64                         `json:"object_size_less_than,omitempty"`
+	Transitions                   []LifecycleTransition         `json:"transitions,omitempty"`
+	ExpirationDays                int32                         `json:"expiration_days,omitempty"`
+	ExpirationDate                string                        `json:"expiration_date,omitempty"`
+	ExpiredObjectDeleteMarker     bool                          `json:"expired_object_delete_marker,omitempty"`
+	NoncurrentVersionTransitions  []NoncurrentVersionTransition `json:"noncurrent_version_transitions,omitempty"`
+	NoncurrentVersionExpiration   int32                         `json:"noncurrent_version_expiration_days,omitempty"`
+	NewerNoncurrentVersions       int32                         `json:"newer_noncurrent_versions,omitempty"` // kept by the noncurrent expiration
+	AbortIncompleteMultipartAfter int32                         `json:"abort_incomplete_multipart_upload_days,omitempty"`
+}
+
+// LifecycleConfiguration is the full set of lifecycle rules of a bucket
+type LifecycleConfiguration struct {
+	Rules []LifecycleRule `json:"rules"`
+}
+
+// LifecycleMatch is an object a lifecycle rule applies to
+type LifecycleMatch struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	StorageClass string `json:"storage_class"`
+	AgeDays      int    `json:"age_days"`
+	Action       string `json:"action"`
+	DueDate      string `json:"due_date,omitempty"`
+}
+
+// LifecycleDryRunResult reports which existing objects a rule would act on
+type LifecycleDryRunResult struct {
+	RuleID         string           `json:"rule_id"`
+	ObjectsScanned int              `json:"objects_scanned"`
+	MatchedObjects int              `json:"matched_objects"`
+	MatchedBytes   int64            `json:"matched_bytes"`
+	DueNow         map[string]int   `json:"due_now"`
+	Truncated      bool             `json:"truncated"`
+	Sample         []LifecycleMatch `json:"sample"`
+}
+
+var transitionStorageClasses = map[string]bool{
+	string(types.TransitionStorageClassStandardIa):         true,
+	string(types.TransitionStorageClassOnezoneIa):          true,
+	string(types.TransitionStorageClassIntelligentTiering): true,
+	string(types.TransitionStorageClassGlacierIr):          true,
+	string(types.TransitionStorageClassGlacier):            true,
+	string(types.TransitionStorageClassDeepArchive):        true,
+}
+
+// GetLifecycleConfiguration reads the lifecycle rules of a bucket
+func (s *S3Service) GetLifecycleConfiguration(bucketName string) (*LifecycleConfiguration, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.GetBucketLifecycleConfiguration(context.TODO(), &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		if isAPIError(err, "NoSuchLifecycleConfiguration") {
+			return &LifecycleConfiguration{Rules: []LifecycleRule{}}, nil
+		}
+		return nil, fmt.Errorf("failed to get lifecycle configuration: %w", err)
+	}
+
+	config := &LifecycleConfiguration{Rules: []LifecycleRule{}}
+	for _, rule := range output.Rules {
+		config.Rules = append(config.Rules, fromSDKLifecycleRule(rule))
+	}
+	return config, nil
+}
+
+// ValidateLifecycleConfiguration returns every problem found in the rules
+func ValidateLifecycleConfiguration(config LifecycleConfiguration) []string {
+	var problems []string
+	seen := make(map[string]bool)
+
+	for i, rule := range config.Rules {
+		name := fmt.Sprintf("rule %d", i+1)
+		if rule.ID != "" {
+			name = fmt.Sprintf("rule %q", rule.ID)
+		}
+
+		switch {
+		case rule.ID == "":
+			problems = append(problems, name+": id is required")
+		case len(rule.ID) > 255:
+			problems = append(problems, name+": id must be at most 255 characters")
+		case seen[rule.ID]:
+			problems = append(problems, name+": id is used more than once")
+		}
+		seen[rule.ID] = true
+
+		if len(rule.Transitions) == 0 && rule.ExpirationDays == 0 && rule.ExpirationDate == "" && !rule.ExpiredObjectDeleteMarker &&
+			len(rule.NoncurrentVersionTransitions) == 0 && rule.NoncurrentVersionExpiration == 0 && rule.AbortIncompleteMultipartAfter == 0 {
+			problems = append(problems, name+": at least one action is required")
+		}
+
+		if rule.ObjectSizeGreaterThan < 0 || rule.ObjectSizeLessThan < 0 {
+			problems = append(problems, name+": object size filters must not be negative")
+		}
+		if rule.ObjectSizeLessThan > 0 && rule.ObjectSizeGreaterThan >= rule.ObjectSizeLessThan {
+			problems = append(problems, name+": object_size_greater_than must be less than object_size_less_than")
+		}
+
+		var lastDays int32 = -1
+		var lastDate string
+		dated := 0
+		for _, transition := range rule.Transitions {
+			if !transitionStorageClasses[transition.StorageClass] {
+				problems = append(problems, fmt.Sprintf("%s: unsupported transition storage class %q", name, transition.StorageClass))
+			}
+			if transition.Date != "" {
+				dated++
+				if _, err := time.Parse(lifecycleDateLayout, transition.Date); err != nil {
+					problems = append(problems, fmt.Sprintf("%s: transition date %q must be in YYYY-MM-DD format", name, transition.Date))
+				}
+				if transition.Date <= lastDate {
+					problems = append(problems, name+": transitions must be ordered by increasing date")
+				}
+				lastDate = transition.Date
+				continue
+			}
+			if transition.Days < 0 {
+				problems = append(problems, name+": transition days must not be negative")
+			}
+			if (transition.StorageClass == "STANDARD_IA" || transition.StorageClass == "ONEZONE_IA") && transition.Days < 30 {
+				problems = append(problems, fmt.Sprintf("%s: transition to %s requires at least 30 days", name, transition.StorageClass))
+			}
+			if transition.Days <= lastDays {
+				problems = append(problems, name+": transitions must be ordered by increasing days")
+			}
+			lastDays = transition.Days
+		}
+		if dated > 0 && dated < len(rule.Transitions) {
+			problems = append(problems, name+": transitions must all use days or all use dates")
+		}
+
+		expirations := 0
+		for _, set := range []bool{rule.ExpirationDays != 0, rule.ExpirationDate != "", rule.ExpiredObjectDeleteMarker} {
+			if set {
+				expirations++
+			}
+		}
+		if expirations > 1 {
+			problems = append(problems, name+": only one of expiration_days, expiration_date or expired_object_delete_marker may be set")
+		}
+		if rule.ExpirationDate != "" {
+			if _, err := time.Parse(lifecycleDateLayout, rule.ExpirationDate); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: expiration date %q must be in YYYY-MM-DD format", name, rule.ExpirationDate))
+			} else if rule.ExpirationDate <= lastDate {
+				problems = append(problems, name+": expiration must come after the last transition")
+			}
+		}
+		if rule.ExpiredObjectDeleteMarker && len(rule.Tags) > 0 {
+			problems = append(problems, name+": expired object delete marker cannot be combined with a tag filter")
+		}
+
+		var lastNoncurrentDays int32
+		for _, transition := range rule.NoncurrentVersionTransitions {
+			if !transitionStorageClasses[transition.StorageClass] {
+				problems = append(problems, fmt.Sprintf("%s: unsupported noncurrent transition storage class %q", name, transition.StorageClass))
+			}
+			if transition.NoncurrentDays <= 0 {
+				problems = append(problems, name+": noncurrent transition days must be positive")
+			}
+			if (transition.StorageClass == "STANDARD_IA" || transition.StorageClass == "ONEZONE_IA") && transition.NoncurrentDays < 30 {
+				problems = append(problems, fmt.Sprintf("%s: noncurrent transition to %s requires at least 30 days", name, transition.StorageClass))
+			}
+			if transition.NoncurrentDays <= lastNoncurrentDays {
+				problems = append(problems, name+": noncurrent transitions must be ordered by increasing days")
+			}
+			if transition.NewerNoncurrentVersions < 0 || transition.NewerNoncurrentVersions > 100 {
+				problems = append(problems, name+": newer_noncurrent_versions must be between 0 and 100")
+			}
+			lastNoncurrentDays = transition.NoncurrentDays
+		}
+		if rule.NoncurrentVersionExpiration > 0 && rule.NoncurrentVersionExpiration <= lastNoncurrentDays {
+			problems = append(problems, name+": noncurrent expiration must come after the last noncurrent transition")
+		}
+		if rule.NewerNoncurrentVersions < 0 || rule.NewerNoncurrentVersions > 100 {
+			problems = append(problems, name+": newer_noncurrent_versions must be between 0 and 100")
+		}
+		if rule.NewerNoncurrentVersions > 0 && rule.NoncurrentVersionExpiration == 0 {
+			problems = append(problems, name+": newer_noncurrent_versions requires noncurrent_version_expiration_days")
+		}
+
+		if rule.ExpirationDays < 0 || rule.NoncurrentVersionExpiration < 0 || rule.AbortIncompleteMultipartAfter < 0 {
+			problems = append(problems, name+": days must not be negative")
+		}
+		if rule.ExpirationDays > 0 && rule.ExpirationDays <= lastDays {
+			problems = append(problems, name+": expiration must come after the last transition")
+		}
+		if rule.AbortIncompleteMultipartAfter > 0 && len(rule.Tags) > 0 {
+			problems = append(problems, name+": abort incomplete multipart upload cannot be combined with a tag filter")
+		}
+	}
+
+	return problems
+}
+
+// PutLifecycleConfiguration validates and replaces the lifecycle rules of a bucket.
+// An empty rule list removes the lifecycle configuration.
+func (s *S3Service) PutLifecycleConfiguration(bucketName string, config LifecycleConfiguration) error {
+	if err := validationError(ValidateLifecycleConfiguration(config)); err != nil {
+		return err
+	}
+
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	if len(config.Rules) == 0 {
+		_, err := client.DeleteBucketLifecycle(context.TODO(), &s3.DeleteBucketLifecycleInput{
+			Bucket: aws.String(bucketName),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete lifecycle configuration: %w", err)
+		}
+		return nil
+	}
+
+	var rules []types.LifecycleRule
+	for _, rule := range config.Rules {
+		rules = append(rules, toSDKLifecycleRule(rule))
+	}
+
+	_, err = client.PutBucketLifecycleConfiguration(context.TODO(), &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucketName),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: rules},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put lifecycle configuration: %w", err)
+	}
+	return nil
+}
+
+// DryRunLifecycleRule reports which current objects a rule would match and what it would do to them.
+// Noncurrent versions and incomplete multipart uploads are not evaluated.
+func (s *S3Service) DryRunLifecycleRule(bucketName string, rule LifecycleRule) (*LifecycleDryRunResult, error) {
+	if err := validationError(ValidateLifecycleConfiguration(LifecycleConfiguration{Rules: []LifecycleRule{rule}})); err != nil {
+		return nil, err
+	}
+
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result := &LifecycleDryRunResult{
+		RuleID: rule.ID,
+		DueNow: make(map[string]int),
+		Sample: []LifecycleMatch{},
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(rule.Prefix),
+	})
+	for paginator.HasMorePages() && !result.Truncated {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			if result.ObjectsScanned >= lifecycleDryRunMaxObjects {
+				result.Truncated = true
+				break
+			}
+			result.ObjectsScanned++
+
+			size := aws.ToInt64(object.Size)
+			if rule.ObjectSizeGreaterThan > 0 && size <= rule.ObjectSizeGreaterThan {
+				continue
+			}
+			if rule.ObjectSizeLessThan > 0 && size >= rule.ObjectSizeLessThan {
+				continue
+			}
+			if len(rule.Tags) > 0 {
+				matches, err := objectHasTags(ctx, client, bucketName, aws.ToString(object.Key), rule.Tags)
+				if err != nil {
+					return nil, err
+				}
+				if !matches {
+					continue
+				}
+			}
+
+			match := evaluateLifecycleRule(rule, aws.ToString(object.Key), size, string(object.StorageClass), aws.ToTime(object.LastModified))
+			result.MatchedObjects++
+			result.MatchedBytes += size
+			if match.DueDate == "" {
+				result.DueNow[match.Action]++
+			}
+			if len(result.Sample) < lifecycleDryRunSampleSize {
+				result.Sample = append(result.Sample, match)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// evaluateLifecycleRule works out the action a rule takes on an object today,
+// or the next action and when it becomes due
+func evaluateLifecycleRule(rule LifecycleRule, key string, size int64, storageClass string, lastModified time.Time) LifecycleMatch {
+	age := int(time.Since(lastModified).Hours() / 24)
+	match := LifecycleMatch{
+		Key:          key,
+		Size:         size,
+		StorageClass: storageClass,
+		AgeDays:      age,
+		Action:       "none",
+	}
+
+	// Each step is due a number of days after the object was created, or on a fixed date
+	type step struct {
+		due    time.Time
+		action string
+	}
+	dueOn := func(days int32, date string) time.Time {
+		if date != "" {
+			due, _ := time.Parse(lifecycleDateLayout, date)
+			return due
+		}
+		return lastModified.AddDate(0, 0, int(days))
+	}
+	var steps []step
+	for _, transition := range rule.Transitions {
+		steps = append(steps, step{dueOn(transition.Days, transition.Date), "transition to " + transition.StorageClass})
+	}
+	if rule.ExpirationDays > 0 || rule.ExpirationDate != "" {
+		steps = append(steps, step{dueOn(rule.ExpirationDays, rule.ExpirationDate), "expire"})
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].due.Before(steps[j].due) })
+
+	now := time.Now()
+	for _, st := range steps {
+		if !st.due.After(now) {
+			match.Action = st.action
+			match.DueDate = ""
+			continue
+		}
+		if match.Action == "none" {
+			match.Action = st.action
+			match.DueDate = st.due.Format(lifecycleDateLayout)
+		}
+		break
+	}
+	return match
+}
+
+func objectHasTags(ctx context.Context, client *s3.Client, bucketName, key string, tags map[string]string) (bool, error) {
+	output, err := client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get tags of %s: %w", key, err)
+	}
+
+	found := 0
+	for _, tag := range output.TagSet {
+		if value, ok := tags[aws.ToString(tag.Key)]; ok && value == aws.ToString(tag.Value) {
+			found++
+		}
+	}
+	return found == len(tags), nil
+}
+
+func toSDKLifecycleRule(rule LifecycleRule) types.LifecycleRule {
+	sdkRule := types.LifecycleRule{
+		ID:     aws.String(rule.ID),
+		Status: types.ExpirationStatusDisabled,
+		Filter: &types.LifecycleRuleFilter{},
+	}
+	if rule.Enabled {
+		sdkRule.Status = types.ExpirationStatusEnabled
+	}
+
+	var tags []types.Tag
+	for key, value := range rule.Tags {
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	sort.Slice(tags, func(i, j int) bool { return aws.ToString(tags[i].Key) < aws.ToString(tags[j].Key) })
+
+	conditions := len(tags)
+	if rule.Prefix != "" {
+		conditions++
+	}
+	if rule.ObjectSizeGreaterThan > 0 {
+		conditions++
+	}
+	if rule.ObjectSizeLessThan > 0 {
+		conditions++
+	}
+
+	// A filter with more than one condition has to be wrapped in an And operator
+	if conditions > 1 {
+		and := &types.LifecycleRuleAndOperator{Tags: tags}
+		if rule.Prefix != "" {
+			and.Prefix = aws.String(rule.Prefix)
+		}
+		if rule.ObjectSizeGreaterThan > 0 {
+			and.ObjectSizeGreaterThan = aws.Int64(rule.ObjectSizeGreaterThan)
+		}
+		if rule.ObjectSizeLessThan > 0 {
+			and.ObjectSizeLessThan = aws.Int64(rule.ObjectSizeLessThan)
+		}
+		sdkRule.Filter.And = and
+	} else {
+		switch {
+		case len(tags) == 1:
+			sdkRule.Filter.Tag = &tags[0]
+		case rule.ObjectSizeGreaterThan > 0:
+			sdkRule.Filter.ObjectSizeGreaterThan = aws.Int64(rule.ObjectSizeGreaterThan)
+		case rule.ObjectSizeLessThan > 0:
+			sdkRule.Filter.ObjectSizeLessThan = aws.Int64(rule.ObjectSizeLessThan)
+		default:
+			sdkRule.Filter.Prefix = aws.String(rule.Prefix)
+		}
+	}
+
+	for _, transition := range rule.Transitions {
+		sdkTransition := types.Transition{StorageClass: types.TransitionStorageClass(transition.StorageClass)}
+		if transition.Date != "" {
+			sdkTransition.Date = lifecycleDate(transition.Date)
+		} else {
+			sdkTransition.Days = aws.Int32(transition.Days)
+		}
+		sdkRule.Transitions = append(sdkRule.Transitions, sdkTransition)
+	}
+	switch {
+	case rule.ExpirationDays > 0:
+		sdkRule.Expiration = &types.LifecycleExpiration{Days: aws.Int32(rule.ExpirationDays)}
+	case rule.ExpirationDate != "":
+		sdkRule.Expiration = &types.LifecycleExpiration{Date: lifecycleDate(rule.ExpirationDate)}
+	case rule.ExpiredObjectDeleteMarker:
+		sdkRule.Expiration = &types.LifecycleExpiration{ExpiredObjectDeleteMarker: aws.Bool(true)}
+	}
+	for _, transition := range rule.NoncurrentVersionTransitions {
+		sdkTransition := types.NoncurrentVersionTransition{
+			NoncurrentDays: aws.Int32(transition.NoncurrentDays),
+			StorageClass:   types.TransitionStorageClass(transition.StorageClass),
+		}
+		if transition.NewerNoncurrentVersions > 0 {
+			sdkTransition.NewerNoncurrentVersions = aws.Int32(transition.NewerNoncurrentVersions)
+		}
+		sdkRule.NoncurrentVersionTransitions = append(sdkRule.NoncurrentVersionTransitions, sdkTransition)
+	}
+	if rule.NoncurrentVersionExpiration > 0 {
+		sdkRule.NoncurrentVersionExpiration = &types.NoncurrentVersionExpiration{
+			NoncurrentDays: aws.Int32(rule.NoncurrentVersionExpiration),
+		}
+		if rule.NewerNoncurrentVersions > 0 {
+			sdkRule.NoncurrentVersionExpiration.NewerNoncurrentVersions = aws.Int32(rule.NewerNoncurrentVersions)
+		}
+	}
+	if rule.AbortIncompleteMultipartAfter > 0 {
+		sdkRule.AbortIncompleteMultipartUpload = &types.AbortIncompleteMultipartUpload{
+			DaysAfterInitiation: aws.Int32(rule.AbortIncompleteMultipartAfter),
+		}
+	}
+
+	return sdkRule
+}
+
+func fromSDKLifecycleRule(sdkRule types.LifecycleRule) LifecycleRule {
+	rule := LifecycleRule{
+		ID:      aws.ToString(sdkRule.ID),
+		Enabled: sdkRule.Status == types.ExpirationStatusEnabled,
+		Prefix:  aws.ToString(sdkRule.Prefix), // deprecated top-level prefix
+	}
+
+	addTag := func(tag types.Tag) {
+		if rule.Tags == nil {
+			rule.Tags = make(map[string]string)
+		}
+		rule.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	if filter := sdkRule.Filter; filter != nil {
+		if filter.Prefix != nil {
+			rule.Prefix = aws.ToString(filter.Prefix)
+		}
+		if filter.Tag != nil {
+			addTag(*filter.Tag)
+		}
+		rule.ObjectSizeGreaterThan = aws.ToInt64(filter.ObjectSizeGreaterThan)
+		rule.ObjectSizeLessThan = aws.ToInt64(filter.ObjectSizeLessThan)
+
+		if and := filter.And; and != nil {
+			rule.Prefix = aws.ToString(and.Prefix)
+			rule.ObjectSizeGreaterThan = aws.ToInt64(and.ObjectSizeGreaterThan)
+			rule.ObjectSizeLessThan = aws.ToInt64(and.ObjectSizeLessThan)
+			for _, tag := range and.Tags {
+				addTag(tag)
+			}
+		}
+	}
+
+	for _, transition := range sdkRule.Transitions {
+		rule.Transitions = append(rule.Transitions, LifecycleTransition{
+			Days:         aws.ToInt32(transition.Days),
+			Date:         formatLifecycleDate(transition.Date),
+			StorageClass: string(transition.StorageClass),
+		})
+	}
+	if expiration := sdkRule.Expiration; expiration != nil {
+		rule.ExpirationDays = aws.ToInt32(expiration.Days)
+		rule.ExpirationDate = formatLifecycleDate(expiration.Date)
+		rule.ExpiredObjectDeleteMarker = aws.ToBool(expiration.ExpiredObjectDeleteMarker)
+	}
+	for _, transition := range sdkRule.NoncurrentVersionTransitions {
+		rule.NoncurrentVersionTransitions = append(rule.NoncurrentVersionTransitions, NoncurrentVersionTransition{
+			NoncurrentDays:          aws.ToInt32(transition.NoncurrentDays),
+			StorageClass:            string(transition.StorageClass),
+			NewerNoncurrentVersions: aws.ToInt32(transition.NewerNoncurrentVersions),
+		})
+	}
+	if sdkRule.NoncurrentVersionExpiration != nil {
+		rule.NoncurrentVersionExpiration = aws.ToInt32(sdkRule.NoncurrentVersionExpiration.NoncurrentDays)
+		rule.NewerNoncurrentVersions = aws.ToInt32(sdkRule.NoncurrentVersionExpiration.NewerNoncurrentVersions)
+	}
+	if sdkRule.AbortIncompleteMultipartUpload != nil {
+		rule.AbortIncompleteMultipartAfter = aws.ToInt32(sdkRule.AbortIncompleteMultipartUpload.DaysAfterInitiation)
+	}
+
+	return rule
+}
+
+// lifecycleDate converts a validated YYYY-MM-DD date to midnight UTC
+func lifecycleDate(date string) *time.Time {
+	parsed, _ := time.Parse(lifecycleDateLayout, date)
+	return &parsed
+}
+
+func formatLifecycleDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.UTC().Format(lifecycleDateLayout)
+}
+
+// isAPIError reports whether err is an AWS API error with one of the given codes
+func isAPIError(err error, codes ...string) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range codes {
+		if strings.EqualFold(apiErr.ErrorCode(), code) {
+			return true
+		}
+	}
+	return false
+}
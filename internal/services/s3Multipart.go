@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// MultipartUploadInfo describes an in-progress multipart upload
+type MultipartUploadInfo struct {
+	Bucket       string  `json:"bucket"`
+	Key          string  `json:"key"`
+	UploadID     string  `json:"upload_id"`
+	Initiated    string  `json:"initiated"`
+	AgeDays      float64 `json:"age_days"`
+	Initiator    string  `json:"initiator"`
+	StorageClass string  `json:"storage_class"`
+	PartCount    int     `json:"part_count"`
+	SizeBytes    int64   `json:"size_bytes"`
+	Error        string  `json:"error,omitempty"`
+}
+
+// MultipartCleanupReport lists the uploads an abort run selected and what happened to them.
+// A dry run counts the selected uploads in WouldAbort and WouldFreeBytes and aborts nothing.
+type MultipartCleanupReport struct {
+	DryRun         bool                  `json:"dry_run"`
+	OlderThanDays  float64               `json:"older_than_days"`
+	Aborted        int                   `json:"aborted"`
+	WouldAbort     int                   `json:"would_abort"`
+	Failed         int                   `json:"failed"`
+	FreedBytes     int64                 `json:"freed_bytes"`
+	WouldFreeBytes int64                 `json:"would_free_bytes"`
+	Uploads        []MultipartUploadInfo `json:"uploads"`
+}
+
+// ListMultipartUploads lists the in-progress multipart uploads of a bucket, or of every
+// bucket when bucketName is empty, with the number and total size of uploaded parts.
+// When listing every bucket, a bucket that cannot be read is returned as an entry with only
+// Bucket and Error set.
+func (s *S3Service) ListMultipartUploads(bucketName string) ([]MultipartUploadInfo, error) {
+	bucketNames := []string{bucketName}
+	if bucketName == "" {
+		buckets, err := s.ListBuckets(false)
+		if err != nil {
+			return nil, err
+		}
+		bucketNames = bucketNames[:0]
+		for _, bucket := range buckets {
+			bucketNames = append(bucketNames, bucket.Name)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	uploads := []MultipartUploadInfo{}
+	for _, name := range bucketNames {
+		client, err := s.clientForBucket(name)
+		var bucketUploads []MultipartUploadInfo
+		if err == nil {
+			bucketUploads, err = listBucketMultipartUploads(ctx, client, name)
+		}
+		if err != nil {
+			if bucketName != "" {
+				return nil, err
+			}
+			// One unreadable bucket should not hide the uploads of all the others
+			uploads = append(uploads, MultipartUploadInfo{Bucket: name, Error: err.Error()})
+			continue
+		}
+		uploads = append(uploads, bucketUploads...)
+	}
+
+	// Oldest uploads first, those are the likeliest to be abandoned
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].AgeDays > uploads[j].AgeDays
+	})
+	return uploads, nil
+}
+
+// AbortStaleMultipartUploads aborts uploads initiated more than olderThanDays ago.
+// With dryRun set it only reports which uploads would be aborted.
+func (s *S3Service) AbortStaleMultipartUploads(bucketName string, olderThanDays float64, dryRun bool) (*MultipartCleanupReport, error) {
+	if olderThanDays <= 0 {
+		return nil, validationError([]string{"olderThanDays must be greater than zero"})
+	}
+
+	uploads, err := s.ListMultipartUploads(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MultipartCleanupReport{
+		DryRun:        dryRun,
+		OlderThanDays: olderThanDays,
+		Uploads:       []MultipartUploadInfo{},
+	}
+
+	for _, upload := range uploads {
+		if upload.UploadID == "" && upload.Error != "" {
+			report.Failed++
+			report.Uploads = append(report.Uploads, upload)
+			continue
+		}
+		if upload.AgeDays < olderThanDays {
+			continue
+		}
+
+		if dryRun {
+			report.WouldAbort++
+			report.WouldFreeBytes += upload.SizeBytes
+			report.Uploads = append(report.Uploads, upload)
+			continue
+		}
+
+		client, err := s.clientForBucket(upload.Bucket)
+		if err == nil {
+			_, err = client.AbortMultipartUpload(context.TODO(), &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(upload.Bucket),
+				Key:      aws.String(upload.Key),
+				UploadId: aws.String(upload.UploadID),
+			})
+		}
+		if err != nil {
+			upload.Error = err.Error()
+			report.Failed++
+			report.Uploads = append(report.Uploads, upload)
+			continue
+		}
+
+		report.Aborted++
+		report.FreedBytes += upload.SizeBytes
+		report.Uploads = append(report.Uploads, upload)
+	}
+
+	return report, nil
+}
+
+func listBucketMultipartUploads(ctx context.Context, client *s3.Client, bucketName string) ([]MultipartUploadInfo, error) {
+	var uploads []MultipartUploadInfo
+
+	paginator := s3.NewListMultipartUploadsPaginator(client, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(bucketName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list multipart uploads of %s: %w", bucketName, err)
+		}
+
+		for _, upload := range page.Uploads {
+			info := MultipartUploadInfo{
+				Bucket:       bucketName,
+				Key:          aws.ToString(upload.Key),
+				UploadID:     aws.ToString(upload.UploadId),
+				Initiated:    formatTime(upload.Initiated),
+				AgeDays:      time.Since(aws.ToTime(upload.Initiated)).Hours() / 24,
+				StorageClass: string(upload.StorageClass),
+			}
+			if upload.Initiator != nil {
+				info.Initiator = aws.ToString(upload.Initiator.DisplayName)
+				if info.Initiator == "" {
+					info.Initiator = aws.ToString(upload.Initiator.ID)
+				}
+			}
+
+			// Part details are best effort; the upload may complete or be aborted meanwhile
+			parts := s3.NewListPartsPaginator(client, &s3.ListPartsInput{
+				Bucket:   aws.String(bucketName),
+				Key:      upload.Key,
+				UploadId: upload.UploadId,
+			})
+			for parts.HasMorePages() {
+				partsPage, err := parts.NextPage(ctx)
+				if err != nil {
+					info.Error = err.Error()
+					break
+				}
+				for _, part := range partsPage.Parts {
+					info.PartCount++
+					info.SizeBytes += aws.ToInt64(part.Size)
+				}
+			}
+
+			uploads = append(uploads, info)
+		}
+	}
+
+	return uploads, nil
+}
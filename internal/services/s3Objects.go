@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// maxUserMetadataSize is the S3 limit on the combined size of user metadata keys and values
+	maxUserMetadataSize = 2 * 1024
+	maxObjectTags       = 10
+)
+
+var objectStorageClasses = map[string]bool{
+	string(types.StorageClassStandard):           true,
+	string(types.StorageClassStandardIa):         true,
+	string(types.StorageClassOnezoneIa):          true,
+	string(types.StorageClassIntelligentTiering): true,
+	string(types.StorageClassGlacierIr):          true,
+	string(types.StorageClassGlacier):            true,
+	string(types.StorageClassDeepArchive):        true,
+}
+
+// ObjectMetadata holds the head metadata of an object
+type ObjectMetadata struct {
+	Bucket               string            `json:"bucket"`
+	Key                  string            `json:"key"`
+	VersionID            string            `json:"version_id,omitempty"`
+	Size                 int64             `json:"size"`
+	ETag                 string            `json:"etag"`
+	LastModified         string            `json:"last_modified"`
+	StorageClass         string            `json:"storage_class"`
+	ContentType          string            `json:"content_type,omitempty"`
+	ContentEncoding      string            `json:"content_encoding,omitempty"`
+	ContentDisposition   string            `json:"content_disposition,omitempty"`
+	ContentLanguage      string            `json:"content_language,omitempty"`
+	CacheControl         string            `json:"cache_control,omitempty"`
+	ServerSideEncryption string            `json:"server_side_encryption,omitempty"`
+	SSEKMSKeyID          string            `json:"sse_kms_key_id,omitempty"`
+	Restore              string            `json:"restore,omitempty"`
+	Metadata             map[string]string `json:"metadata"`
+}
+
+// ObjectMetadataUpdate lists the metadata to change. Headers left nil keep their current value;
+// a non-nil Metadata map replaces all user metadata.
+type ObjectMetadataUpdate struct {
+	Metadata           map[string]string `json:"metadata"`
+	ContentType        *string           `json:"content_type"`
+	ContentEncoding    *string           `json:"content_encoding"`
+	ContentDisposition *string           `json:"content_disposition"`
+	ContentLanguage    *string           `json:"content_language"`
+	CacheControl       *string           `json:"cache_control"`
+}
+
+// HeadObject returns the metadata of an object, or of one of its versions
+func (s *S3Service) HeadObject(bucketName, key, versionID string) (*ObjectMetadata, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	output, err := client.HeadObject(context.TODO(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object: %w", err)
+	}
+
+	storageClass := string(output.StorageClass)
+	if storageClass == "" {
+		storageClass = string(types.StorageClassStandard)
+	}
+
+	metadata := output.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	return &ObjectMetadata{
+		Bucket:               bucketName,
+		Key:                  key,
+		VersionID:            aws.ToString(output.VersionId),
+		Size:                 aws.ToInt64(output.ContentLength),
+		ETag:                 aws.ToString(output.ETag),
+		LastModified:         formatTime(output.LastModified),
+		StorageClass:         storageClass,
+		ContentType:          aws.ToString(output.ContentType),
+		ContentEncoding:      aws.ToString(output.ContentEncoding),
+		ContentDisposition:   aws.ToString(output.ContentDisposition),
+		ContentLanguage:      aws.ToString(output.ContentLanguage),
+		CacheControl:         aws.ToString(output.CacheControl),
+		ServerSideEncryption: string(output.ServerSideEncryption),
+		SSEKMSKeyID:          aws.ToString(output.SSEKMSKeyId),
+		Restore:              aws.ToString(output.Restore),
+		Metadata:             metadata,
+	}, nil
+}
+
+// UpdateObjectMetadata rewrites user metadata and content headers by copying the object onto itself.
+// Storage class, encryption and tags are preserved.
+func (s *S3Service) UpdateObjectMetadata(bucketName, key string, update ObjectMetadataUpdate) (*ObjectMetadata, error) {
+	if err := validationError(validateUserMetadata(update.Metadata)); err != nil {
+		return nil, err
+	}
+
+	current, err := s.HeadObject(bucketName, key, "")
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := current.Metadata
+	if update.Metadata != nil {
+		metadata = update.Metadata
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:             aws.String(bucketName),
+		Key:                aws.String(key),
+		CopySource:         aws.String(copySource(bucketName, key, "")),
+		MetadataDirective:  types.MetadataDirectiveReplace,
+		Metadata:           metadata,
+		ContentType:        optionalString(update.ContentType, current.ContentType),
+		ContentEncoding:    optionalString(update.ContentEncoding, current.ContentEncoding),
+		ContentDisposition: optionalString(update.ContentDisposition, current.ContentDisposition),
+		ContentLanguage:    optionalString(update.ContentLanguage, current.ContentLanguage),
+		CacheControl:       optionalString(update.CacheControl, current.CacheControl),
+	}
+
+	if err := s.copyInPlace(current, input); err != nil {
+		return nil, err
+	}
+	return s.HeadObject(bucketName, key, "")
+}
+
+// ChangeStorageClass moves an object to another storage class by copying it onto itself
+func (s *S3Service) ChangeStorageClass(bucketName, key, storageClass string) (*ObjectMetadata, error) {
+	if !objectStorageClasses[storageClass] {
+		return nil, validationError([]string{fmt.Sprintf("unsupported storage class %q", storageClass)})
+	}
+
+	current, err := s.HeadObject(bucketName, key, "")
+	if err != nil {
+		return nil, err
+	}
+	if current.StorageClass == storageClass {
+		return current, nil
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(bucketName),
+		Key:               aws.String(key),
+		CopySource:        aws.String(copySource(bucketName, key, "")),
+		MetadataDirective: types.MetadataDirectiveCopy,
+		StorageClass:      types.StorageClass(storageClass),
+	}
+
+	if err := s.copyInPlace(current, input); err != nil {
+		return nil, err
+	}
+	return s.HeadObject(bucketName, key, "")
+}
+
+// GetObjectTags returns the tags of an object
+func (s *S3Service) GetObjectTags(bucketName, key string) (map[string]string, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.GetObjectTagging(context.TODO(), &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object tags: %w", err)
+	}
+
+	tags := make(map[string]string)
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// PutObjectTags replaces all tags of an object
+func (s *S3Service) PutObjectTags(bucketName, key string, tags map[string]string) error {
+	if err := validationError(validateObjectTags(tags)); err != nil {
+		return err
+	}
+
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	tagSet := []types.Tag{}
+	for k, v := range tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	sort.Slice(tagSet, func(i, j int) bool { return aws.ToString(tagSet[i].Key) < aws.ToString(tagSet[j].Key) })
+
+	_, err = client.PutObjectTagging(context.TODO(), &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucketName),
+		Key:     aws.String(key),
+		Tagging: &types.Tagging{TagSet: tagSet},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put object tags: %w", err)
+	}
+	return nil
+}
+
+// copyInPlace runs a self-copy, carrying over the encryption settings and, unless the
+// input sets one, the storage class of the current object
+func (s *S3Service) copyInPlace(current *ObjectMetadata, input *s3.CopyObjectInput) error {
+	if current.Size > maxSingleCopySize {
+		return fmt.Errorf("objects larger than 5GB cannot be modified in place")
+	}
+
+	client, err := s.clientForBucket(current.Bucket)
+	if err != nil {
+		return err
+	}
+
+	if input.StorageClass == "" {
+		input.StorageClass = types.StorageClass(current.StorageClass)
+	}
+	if current.ServerSideEncryption != "" {
+		input.ServerSideEncryption = types.ServerSideEncryption(current.ServerSideEncryption)
+	}
+	if current.SSEKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(current.SSEKMSKeyID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if _, err := client.CopyObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to copy object in place: %w", err)
+	}
+	return nil
+}
+
+func validateUserMetadata(metadata map[string]string) []string {
+	var problems []string
+	size := 0
+	for k, v := range metadata {
+		size += len(k) + len(v)
+		for _, r := range k + v {
+			if r > 127 {
+				problems = append(problems, fmt.Sprintf("metadata %q must contain only ASCII characters", k))
+				break
+			}
+		}
+	}
+	if size > maxUserMetadataSize {
+		problems = append(problems, "user metadata must not exceed 2KB")
+	}
+	return problems
+}
+
+func validateObjectTags(tags map[string]string) []string {
+	var problems []string
+	if len(tags) > maxObjectTags {
+		problems = append(problems, fmt.Sprintf("at most %d tags are allowed", maxObjectTags))
+	}
+	for k, v := range tags {
+		if k == "" || len(k) > 128 {
+			problems = append(problems, fmt.Sprintf("tag key %q must be 1 to 128 characters", k))
+		}
+		if len(v) > 256 {
+			problems = append(problems, fmt.Sprintf("tag %q value must be at most 256 characters", k))
+		}
+	}
+	return problems
+}
+
+// optionalString returns the override when set, otherwise the current value, and nil for empty strings
+func optionalString(override *string, current string) *string {
+	if override != nil {
+		current = *override
+	}
+	if current == "" {
+		return nil
+	}
+	return aws.String(current)
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+var (
+	s3ActionPattern      = regexp.MustCompile(`^s3:[A-Za-z*?]+$`)
+	accountIDPattern     = regexp.MustCompile(`^\d{12}$`)
+	iamPrincipalPattern  = regexp.MustCompile(`^arn:aws(-cn|-us-gov)?:(iam|sts)::(\d{12}|\*):.+$`)
+	cloudFrontOAIPattern = regexp.MustCompile(`^arn:aws(-cn|-us-gov)?:iam::cloudfront:user/CloudFront Origin Access Identity [A-Z0-9]+$`)
+	policyPrincipalTypes = map[string]bool{"AWS": true, "Service": true, "Federated": true, "CanonicalUser": true}
+)
+
+// PolicyChangePreview reports whether a proposed document is valid and how it differs from the current one
+type PolicyChangePreview struct {
+	Valid    bool          `json:"valid"`
+	Problems []string      `json:"problems"`
+	Diff     *DocumentDiff `json:"diff,omitempty"`
+}
+
+// GetBucketPolicy returns the bucket policy document, or nil if the bucket has none
+func (s *S3Service) GetBucketPolicy(bucketName string) (json.RawMessage, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.GetBucketPolicy(context.TODO(), &s3.GetBucketPolicyInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		if isAPIError(err, "NoSuchBucketPolicy") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get bucket policy: %w", err)
+	}
+
+	return json.RawMessage(aws.ToString(output.Policy)), nil
+}
+
+// PreviewBucketPolicy validates a proposed policy and diffs it against the current one
+func (s *S3Service) PreviewBucketPolicy(bucketName string, proposed []byte) (*PolicyChangePreview, error) {
+	problems := ValidateBucketPolicy(bucketName, proposed)
+	preview := &PolicyChangePreview{Valid: len(problems) == 0, Problems: problems}
+	if !preview.Valid {
+		return preview, nil
+	}
+
+	current, err := s.GetBucketPolicy(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.Diff = diffEntries(policyDiffEntries(current), policyDiffEntries(proposed))
+	return preview, nil
+}
+
+// PutBucketPolicy validates and replaces the bucket policy, returning the applied diff
+func (s *S3Service) PutBucketPolicy(bucketName string, policy []byte) (*DocumentDiff, error) {
+	preview, err := s.PreviewBucketPolicy(bucketName, policy)
+	if err != nil {
+		return nil, err
+	}
+	if !preview.Valid {
+		return nil, validationError(preview.Problems)
+	}
+
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = client.PutBucketPolicy(context.TODO(), &s3.PutBucketPolicyInput{
+		Bucket: aws.String(bucketName),
+		Policy: aws.String(string(policy)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put bucket policy: %w", err)
+	}
+
+	return preview.Diff, nil
+}
+
+// DeleteBucketPolicy removes the bucket policy
+func (s *S3Service) DeleteBucketPolicy(bucketName string) error {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteBucketPolicy(context.TODO(), &s3.DeleteBucketPolicyInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete bucket policy: %w", err)
+	}
+	return nil
+}
+
+// ValidateBucketPolicy checks the structure, principals, actions and resource ARNs of a bucket policy
+func ValidateBucketPolicy(bucketName string, policy []byte) []string {
+	var document map[string]interface{}
+	if err := json.Unmarshal(policy, &document); err != nil {
+		return []string{fmt.Sprintf("policy is not a JSON object: %v", err)}
+	}
+
+	var problems []string
+
+	switch document["Version"] {
+	case "2012-10-17", "2008-10-17":
+	case nil:
+		problems = append(problems, "Version is required")
+	default:
+		problems = append(problems, fmt.Sprintf("unsupported Version %v", document["Version"]))
+	}
+
+	statements, ok := policyStatements(document)
+	if !ok || len(statements) == 0 {
+		return append(problems, "Statement must be a non-empty object or array of objects")
+	}
+
+	resourcePattern := regexp.MustCompile(`^arn:aws(-cn|-us-gov)?:s3:::` + regexp.QuoteMeta(bucketName) + `(/.*)?$`)
+	sids := make(map[string]bool)
+
+	for i, statement := range statements {
+		name := fmt.Sprintf("statement %d", i+1)
+
+		if sid, ok := statement["Sid"].(string); ok {
+			name = fmt.Sprintf("statement %q", sid)
+			if sids[sid] {
+				problems = append(problems, name+": Sid is used more than once")
+			}
+			sids[sid] = true
+		}
+
+		if effect := statement["Effect"]; effect != "Allow" && effect != "Deny" {
+			problems = append(problems, name+": Effect must be Allow or Deny")
+		}
+
+		principalKey := exactlyOneKey(statement, "Principal", "NotPrincipal")
+		if principalKey == "" {
+			problems = append(problems, name+": exactly one of Principal or NotPrincipal is required")
+		} else {
+			problems = append(problems, validatePrincipal(name, statement[principalKey])...)
+		}
+
+		actionKey := exactlyOneKey(statement, "Action", "NotAction")
+		if actionKey == "" {
+			problems = append(problems, name+": exactly one of Action or NotAction is required")
+		} else if actions, ok := stringList(statement[actionKey]); !ok || len(actions) == 0 {
+			problems = append(problems, name+": "+actionKey+" must be a string or array of strings")
+		} else {
+			for _, action := range actions {
+				if action != "*" && !s3ActionPattern.MatchString(action) {
+					problems = append(problems, fmt.Sprintf("%s: %q is not an S3 action", name, action))
+				}
+			}
+		}
+
+		resourceKey := exactlyOneKey(statement, "Resource", "NotResource")
+		if resourceKey == "" {
+			problems = append(problems, name+": exactly one of Resource or NotResource is required")
+		} else if resources, ok := stringList(statement[resourceKey]); !ok || len(resources) == 0 {
+			problems = append(problems, name+": "+resourceKey+" must be a string or array of strings")
+		} else {
+			for _, resource := range resources {
+				if !resourcePattern.MatchString(resource) {
+					problems = append(problems, fmt.Sprintf("%s: resource %q is not an ARN of bucket %s or its objects", name, resource, bucketName))
+				}
+			}
+		}
+
+		if condition, ok := statement["Condition"]; ok {
+			if _, ok := conditionValues(condition); !ok {
+				problems = append(problems, name+": Condition must map operators to objects of condition keys")
+			}
+		}
+	}
+
+	return problems
+}
+
+func validatePrincipal(name string, principal interface{}) []string {
+	if principal == "*" {
+		return nil
+	}
+
+	principals, ok := principal.(map[string]interface{})
+	if !ok || len(principals) == 0 {
+		return []string{name + `: principal must be "*" or an object`}
+	}
+
+	var problems []string
+	for principalType, value := range principals {
+		if !policyPrincipalTypes[principalType] {
+			problems = append(problems, fmt.Sprintf("%s: unknown principal type %q", name, principalType))
+			continue
+		}
+
+		values, ok := stringList(value)
+		if !ok || len(values) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: %s principal must be a string or array of strings", name, principalType))
+			continue
+		}
+
+		for _, v := range values {
+			switch principalType {
+			case "AWS":
+				// CloudFront origin access identities use the cloudfront pseudo-account, as in
+				// "arn:aws:iam::cloudfront:user/CloudFront Origin Access Identity E2QWRUHEXAMPLE"
+				if v != "*" && !accountIDPattern.MatchString(v) && !iamPrincipalPattern.MatchString(v) && !cloudFrontOAIPattern.MatchString(v) {
+					problems = append(problems, fmt.Sprintf("%s: %q is not an account ID, IAM ARN or CloudFront origin access identity", name, v))
+				}
+			case "Service":
+				if !strings.HasSuffix(v, ".amazonaws.com") {
+					problems = append(problems, fmt.Sprintf("%s: %q is not an AWS service principal", name, v))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// policyDiffEntries flattens the statements of a policy for diffing. Statements are matched
+// by Sid; statements without one are identified by a hash of their content.
+func policyDiffEntries(policy []byte) []DiffEntry {
+	if len(policy) == 0 {
+		return nil
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(policy, &document); err != nil {
+		return nil
+	}
+	statements, _ := policyStatements(document)
+
+	var entries []DiffEntry
+	for _, statement := range statements {
+		fields := make(map[string][]string)
+		for key, value := range statement {
+			switch key {
+			case "Sid":
+				continue
+			case "Principal", "NotPrincipal":
+				fields[key] = flattenPrincipal(value)
+			case "Condition":
+				fields[key], _ = conditionValues(value)
+			default:
+				values, ok := stringList(value)
+				if !ok {
+					encoded, _ := json.Marshal(value)
+					values = []string{string(encoded)}
+				}
+				sort.Strings(values)
+				fields[key] = values
+			}
+		}
+
+		id, _ := statement["Sid"].(string)
+		if id == "" {
+			id = "#" + fingerprint(fields)
+		}
+		entries = append(entries, DiffEntry{ID: id, Fields: fields})
+	}
+	return entries
+}
+
+func policyStatements(document map[string]interface{}) ([]map[string]interface{}, bool) {
+	switch statement := document["Statement"].(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{statement}, true
+	case []interface{}:
+		var statements []map[string]interface{}
+		for _, item := range statement {
+			object, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			statements = append(statements, object)
+		}
+		return statements, true
+	}
+	return nil, false
+}
+
+func flattenPrincipal(principal interface{}) []string {
+	if value, ok := principal.(string); ok {
+		return []string{value}
+	}
+
+	var values []string
+	principals, _ := principal.(map[string]interface{})
+	for principalType, value := range principals {
+		list, _ := stringList(value)
+		for _, v := range list {
+			values = append(values, principalType+":"+v)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// conditionValues flattens a Condition block into "Operator:Key=value" strings
+func conditionValues(condition interface{}) ([]string, bool) {
+	operators, ok := condition.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	var values []string
+	for operator, block := range operators {
+		keys, ok := block.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		for key, value := range keys {
+			list, ok := stringList(value)
+			if !ok {
+				encoded, _ := json.Marshal(value)
+				list = []string{string(encoded)}
+			}
+			for _, v := range list {
+				values = append(values, fmt.Sprintf("%s:%s=%s", operator, key, v))
+			}
+		}
+	}
+	sort.Strings(values)
+	return values, true
+}
+
+// stringList accepts the IAM "string or array of strings" form
+func stringList(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, true
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func exactlyOneKey(object map[string]interface{}, a, b string) string {
+	_, hasA := object[a]
+	_, hasB := object[b]
+	switch {
+	case hasA && !hasB:
+		return a
+	case hasB && !hasA:
+		return b
+	}
+	return ""
+}
+
+func fingerprint(fields map[string][]string) string {
+	encoded, _ := json.Marshal(fields) // map keys are sorted by encoding/json
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:4])
+}
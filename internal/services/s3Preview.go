@@ -0,0 +1,300 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for thumbnails
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// DefaultPreviewBytes is how much of an object is read when no size is requested
+	DefaultPreviewBytes = 64 * 1024
+	// MaxPreviewBytes is the hard cap on bytes read, or produced by decompression, for a text preview
+	MaxPreviewBytes = 1024 * 1024
+	// maxPreviewImageBytes caps the size of images that are decoded for a thumbnail
+	maxPreviewImageBytes = 8 * 1024 * 1024
+	// maxPreviewImagePixels caps the dimensions of images that are decoded, since a small
+	// compressed file can expand to gigabytes of pixels
+	maxPreviewImagePixels = 25 * 1000 * 1000
+	// maxParquetFooterBytes caps the Parquet metadata read for a schema preview
+	maxParquetFooterBytes = 1024 * 1024
+	previewCSVRows        = 20
+	thumbnailSize         = 200
+)
+
+// ObjectPreview is a safe, size-capped rendering of the start of an object
+type ObjectPreview struct {
+	Bucket      string         `json:"bucket"`
+	Key         string         `json:"key"`
+	Kind        string         `json:"kind"`
+	ContentType string         `json:"content_type,omitempty"`
+	Size        int64          `json:"size"`
+	Truncated   bool           `json:"truncated"`
+	Compressed  bool           `json:"compressed,omitempty"`
+	Text        string         `json:"text,omitempty"`
+	Rows        [][]string     `json:"rows,omitempty"`
+	Thumbnail   string         `json:"thumbnail,omitempty"`
+	Width       int            `json:"width,omitempty"`
+	Height      int            `json:"height,omitempty"`
+	Parquet     *ParquetSchema `json:"parquet,omitempty"`
+	Message     string         `json:"message,omitempty"`
+}
+
+// PreviewObject renders a preview of an object from at most maxBytes of its content
+func (s *S3Service) PreviewObject(bucketName, key string, maxBytes int64) (*ObjectPreview, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultPreviewBytes
+	}
+	maxBytes = min(maxBytes, MaxPreviewBytes)
+
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	head, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object: %w", err)
+	}
+
+	preview := &ObjectPreview{
+		Bucket:      bucketName,
+		Key:         key,
+		ContentType: aws.ToString(head.ContentType),
+		Size:        aws.ToInt64(head.ContentLength),
+	}
+	extension := strings.ToLower(path.Ext(key))
+
+	switch {
+	case extension == ".parquet":
+		return preview, previewParquet(ctx, client, preview)
+	case strings.HasPrefix(preview.ContentType, "image/") || extension == ".png" || extension == ".jpg" || extension == ".jpeg" || extension == ".gif":
+		if preview.Size <= maxPreviewImageBytes {
+			return preview, previewImage(ctx, client, preview)
+		}
+		preview.Kind = "image"
+		preview.Message = "image is too large to generate a thumbnail"
+		return preview, nil
+	}
+
+	data, err := readObjectRange(ctx, client, bucketName, key, 0, maxBytes-1)
+	if err != nil {
+		return nil, err
+	}
+	preview.Truncated = int64(len(data)) < preview.Size
+
+	innerName := key
+	if extension == ".gz" || aws.ToString(head.ContentEncoding) == "gzip" || bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		data, preview.Truncated = gunzipPrefix(data, maxBytes, preview.Truncated)
+		preview.Compressed = true
+		innerName = strings.TrimSuffix(key, path.Ext(key))
+	}
+
+	renderTextPreview(preview, data, strings.ToLower(path.Ext(innerName)))
+	return preview, nil
+}
+
+// renderTextPreview fills in a text, JSON or CSV preview, or marks the data as binary
+func renderTextPreview(preview *ObjectPreview, data []byte, extension string) {
+	sniffed := http.DetectContentType(data)
+	if !utf8.Valid(trimPartialRune(data)) && !strings.HasPrefix(sniffed, "text/") {
+		preview.Kind = "binary"
+		preview.Message = "no preview available for binary content"
+		return
+	}
+	text := string(trimPartialRune(data))
+
+	switch {
+	case extension == ".json" || strings.Contains(preview.ContentType, "json"):
+		preview.Kind = "json"
+		var indented bytes.Buffer
+		if !preview.Truncated && json.Indent(&indented, data, "", "  ") == nil {
+			text = indented.String()
+		}
+		preview.Text = text
+
+	case extension == ".csv" || extension == ".tsv" || strings.Contains(preview.ContentType, "csv"):
+		preview.Kind = "csv"
+		reader := csv.NewReader(strings.NewReader(text))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		if extension == ".tsv" {
+			reader.Comma = '\t'
+		}
+		for len(preview.Rows) < previewCSVRows {
+			record, err := reader.Read()
+			if err != nil {
+				break
+			}
+			preview.Rows = append(preview.Rows, record)
+		}
+		// The last row of a truncated read is likely cut short
+		if preview.Truncated && len(preview.Rows) > 1 && len(preview.Rows) < previewCSVRows {
+			preview.Rows = preview.Rows[:len(preview.Rows)-1]
+		}
+
+	default:
+		preview.Kind = "text"
+		preview.Text = text
+	}
+}
+
+func previewImage(ctx context.Context, client *s3.Client, preview *ObjectPreview) error {
+	preview.Kind = "image"
+
+	data, err := readObjectRange(ctx, client, preview.Bucket, preview.Key, 0, maxPreviewImageBytes-1)
+	if err != nil {
+		return err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		preview.Message = fmt.Sprintf("unable to decode image: %v", err)
+		return nil
+	}
+	preview.Width, preview.Height = config.Width, config.Height
+	if int64(config.Width)*int64(config.Height) > maxPreviewImagePixels {
+		preview.Message = "image has too many pixels to generate a thumbnail"
+		return nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		preview.Message = fmt.Sprintf("unable to decode image: %v", err)
+		return nil
+	}
+
+	bounds := img.Bounds()
+	preview.Width, preview.Height = bounds.Dx(), bounds.Dy()
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, thumbnail(img, thumbnailSize)); err != nil {
+		return fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	preview.Thumbnail = "data:image/png;base64," + base64.StdEncoding.EncodeToString(encoded.Bytes())
+	return nil
+}
+
+func previewParquet(ctx context.Context, client *s3.Client, preview *ObjectPreview) error {
+	preview.Kind = "parquet"
+	if preview.Size < 12 {
+		preview.Message = "object is too small to be a parquet file"
+		return nil
+	}
+
+	trailer, err := readObjectRange(ctx, client, preview.Bucket, preview.Key, preview.Size-8, preview.Size-1)
+	if err != nil {
+		return err
+	}
+	footerLength, err := parquetFooterLength(trailer)
+	if err != nil {
+		preview.Message = err.Error()
+		return nil
+	}
+	if footerLength > maxParquetFooterBytes || footerLength > preview.Size-12 {
+		preview.Message = "parquet metadata is too large to preview"
+		return nil
+	}
+
+	footer, err := readObjectRange(ctx, client, preview.Bucket, preview.Key, preview.Size-8-footerLength, preview.Size-9)
+	if err != nil {
+		return err
+	}
+
+	schema, err := parseParquetSchema(footer)
+	if err != nil {
+		preview.Message = err.Error()
+		return nil
+	}
+	preview.Parquet = schema
+	return nil
+}
+
+// readObjectRange reads the inclusive byte range [first, last] of an object
+func readObjectRange(ctx context.Context, client *s3.Client, bucketName, key string, first, last int64) ([]byte, error) {
+	output, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", first, last)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(output.Body, last-first+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	return data, nil
+}
+
+// gunzipPrefix decompresses as much of a possibly truncated gzip stream as it can,
+// producing at most maxBytes of output
+func gunzipPrefix(data []byte, maxBytes int64, truncated bool) ([]byte, bool) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, truncated
+	}
+	defer reader.Close()
+
+	// Errors are expected when the compressed input was cut short; keep what was decoded
+	out, _ := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if int64(len(out)) > maxBytes {
+		return out[:maxBytes], true
+	}
+	return out, truncated
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence left at the end by a ranged read
+func trimPartialRune(data []byte) []byte {
+	for i := 0; i < utf8.UTFMax && i < len(data); i++ {
+		if utf8.Valid(data[:len(data)-i]) {
+			return data[:len(data)-i]
+		}
+	}
+	return data
+}
+
+// thumbnail scales an image down to fit in a size x size box using nearest-neighbour sampling
+func thumbnail(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return src
+	}
+
+	scale := float64(size) / float64(max(width, height))
+	dstWidth, dstHeight := max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			dst.Set(x, y, src.At(bounds.Min.X+x*width/dstWidth, bounds.Min.Y+y*height/dstHeight))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// PrefixUsage holds the aggregated size of the objects under a prefix
+type PrefixUsage struct {
+	Prefix    string `json:"prefix"`
+	Objects   int64  `json:"objects"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// StorageClassUsage holds the aggregated size of the objects in a storage class
+type StorageClassUsage struct {
+	StorageClass string `json:"storage_class"`
+	Objects      int64  `json:"objects"`
+	SizeBytes    int64  `json:"size_bytes"`
+}
+
+// BucketScanResult is the outcome of walking every object of a bucket
+type BucketScanResult struct {
+	Bucket         string              `json:"bucket"`
+	Prefix         string              `json:"prefix"`
+	ObjectsScanned int64               `json:"objects_scanned"`
+	TotalSizeBytes int64               `json:"total_size_bytes"`
+	ByPrefix       []PrefixUsage       `json:"by_prefix"`
+	ByStorageClass []StorageClassUsage `json:"by_storage_class"`
+}
+
+// StartBucketScan starts a background job that lists every object under prefix
+// and aggregates sizes by the next prefix level and by storage class
+func (s *S3Service) StartBucketScan(bucketName, prefix string) (JobInfo, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return JobInfo{}, err
+	}
+
+	job := s.Jobs.Start("bucket-scan", func(ctx context.Context, job *Job) (interface{}, error) {
+		return scanBucket(ctx, client, bucketName, prefix, job)
+	})
+	return job, nil
+}
+
+func scanBucket(ctx context.Context, client *s3.Client, bucketName, prefix string, job *Job) (*BucketScanResult, error) {
+	byPrefix := make(map[string]*PrefixUsage)
+	byStorageClass := make(map[string]*StorageClassUsage)
+	var objects, totalSize int64
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	})
+
+	result := func() *BucketScanResult {
+		return buildScanResult(bucketName, prefix, objects, totalSize, byPrefix, byStorageClass)
+	}
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return result(), fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			size := aws.ToInt64(object.Size)
+			objects++
+			totalSize += size
+
+			group := groupPrefix(prefix, aws.ToString(object.Key))
+			if byPrefix[group] == nil {
+				byPrefix[group] = &PrefixUsage{Prefix: group}
+			}
+			byPrefix[group].Objects++
+			byPrefix[group].SizeBytes += size
+
+			storageClass := string(object.StorageClass)
+			if storageClass == "" {
+				storageClass = "STANDARD"
+			}
+			if byStorageClass[storageClass] == nil {
+				byStorageClass[storageClass] = &StorageClassUsage{StorageClass: storageClass}
+			}
+			byStorageClass[storageClass].Objects++
+			byStorageClass[storageClass].SizeBytes += size
+		}
+
+		// Publish a copy so readers never see the maps mid-update
+		job.SetProgress(result())
+	}
+
+	return result(), nil
+}
+
+// groupPrefix returns the prefix one level below the scanned prefix that key belongs to
+func groupPrefix(prefix, key string) string {
+	rest := strings.TrimPrefix(key, prefix)
+	if i := strings.Index(rest, "/"); i >= 0 {
+		return prefix + rest[:i+1]
+	}
+	return prefix
+}
+
+func buildScanResult(bucketName, prefix string, objects, totalSize int64, byPrefix map[string]*PrefixUsage, byStorageClass map[string]*StorageClassUsage) *BucketScanResult {
+	result := &BucketScanResult{
+		Bucket:         bucketName,
+		Prefix:         prefix,
+		ObjectsScanned: objects,
+		TotalSizeBytes: totalSize,
+		ByPrefix:       make([]PrefixUsage, 0, len(byPrefix)),
+		ByStorageClass: make([]StorageClassUsage, 0, len(byStorageClass)),
+	}
+
+	for _, usage := range byPrefix {
+		result.ByPrefix = append(result.ByPrefix, *usage)
+	}
+	sort.Slice(result.ByPrefix, func(i, j int) bool {
+		return result.ByPrefix[i].SizeBytes > result.ByPrefix[j].SizeBytes
+	})
+
+	for _, usage := range byStorageClass {
+		result.ByStorageClass = append(result.ByStorageClass, *usage)
+	}
+	sort.Slice(result.ByStorageClass, func(i, j int) bool {
+		return result.ByStorageClass[i].SizeBytes > result.ByStorageClass[j].SizeBytes
+	})
+
+	return result
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxSelectLineBytes bounds a single JSON line read by the local engine
+const maxSelectLineBytes = 4 * 1024 * 1024
+
+// SelectInput describes a SQL filter over a CSV or JSON-lines object
+type SelectInput struct {
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+	Expression  string `json:"expression"`
+	Format      string `json:"format"`    // csv or json
+	NoHeader    bool   `json:"no_header"` // csv only: the first row is data, columns are _1, _2, ...
+	Delimiter   string `json:"delimiter"` // csv only, defaults to ","
+	Compression string `json:"compression"`
+	Engine      string `json:"engine"` // local (default) or s3select
+}
+
+// Validate checks the input and, for the local engine, parses the expression
+func (input *SelectInput) Validate() error {
+	var problems []string
+	if input.Bucket == "" || input.Key == "" {
+		problems = append(problems, "bucket and key are required")
+	}
+	if input.Expression == "" {
+		problems = append(problems, "expression is required")
+	}
+
+	input.Format = strings.ToLower(input.Format)
+	if input.Format != "csv" && input.Format != "json" {
+		problems = append(problems, "format must be csv or json")
+	}
+	if input.Delimiter == "" {
+		input.Delimiter = ","
+	}
+	if len([]rune(input.Delimiter)) != 1 {
+		problems = append(problems, "delimiter must be a single character")
+	}
+
+	input.Compression = strings.ToUpper(input.Compression)
+	if input.Compression == "" {
+		input.Compression = "NONE"
+	}
+	if input.Compression != "NONE" && input.Compression != "GZIP" {
+		problems = append(problems, "compression must be NONE or GZIP")
+	}
+
+	switch input.Engine {
+	case "", "local":
+		input.Engine = "local"
+		if input.Expression != "" {
+			if _, err := parseSelectQuery(input.Expression); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid expression: %v", err))
+			}
+		}
+	case "s3select":
+	default:
+		problems = append(problems, "engine must be local or s3select")
+	}
+
+	return validationError(problems)
+}
+
+// SelectObject runs the query over the object and calls emit with each matching row as one JSON line.
+// Call Validate on the input first.
+func (s *S3Service) SelectObject(ctx context.Context, input SelectInput, emit func(line []byte) error) error {
+	client, err := s.clientForBucket(input.Bucket)
+	if err != nil {
+		return err
+	}
+
+	if input.Engine == "s3select" {
+		return selectWithS3(ctx, client, input, emit)
+	}
+	return selectLocally(ctx, client, input, emit)
+}
+
+func selectWithS3(ctx context.Context, client *s3.Client, input SelectInput, emit func(line []byte) error) error {
+	serialization := &types.InputSerialization{
+		CompressionType: types.CompressionType(input.Compression),
+	}
+	if input.Format == "csv" {
+		headerInfo := types.FileHeaderInfoUse
+		if input.NoHeader {
+			headerInfo = types.FileHeaderInfoNone
+		}
+		serialization.CSV = &types.CSVInput{
+			FileHeaderInfo: headerInfo,
+			FieldDelimiter: aws.String(input.Delimiter),
+		}
+	} else {
+		serialization.JSON = &types.JSONInput{Type: types.JSONTypeLines}
+	}
+
+	output, err := client.SelectObjectContent(ctx, &s3.SelectObjectContentInput{
+		Bucket:              aws.String(input.Bucket),
+		Key:                 aws.String(input.Key),
+		Expression:          aws.String(input.Expression),
+		ExpressionType:      types.ExpressionTypeSql,
+		InputSerialization:  serialization,
+		OutputSerialization: &types.OutputSerialization{JSON: &types.JSONOutput{RecordDelimiter: aws.String("\n")}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to select object content: %w", err)
+	}
+
+	stream := output.GetStream()
+	defer stream.Close()
+
+	// Records events split the output at arbitrary byte boundaries, so buffer partial lines
+	var pending []byte
+	for event := range stream.Events() {
+		records, ok := event.(*types.SelectObjectContentEventStreamMemberRecords)
+		if !ok {
+			continue
+		}
+
+		pending = append(pending, records.Value.Payload...)
+		for {
+			i := bytes.IndexByte(pending, '\n')
+			if i < 0 {
+				break
+			}
+			if line := bytes.TrimSpace(pending[:i]); len(line) > 0 {
+				if err := emit(line); err != nil {
+					return err
+				}
+			}
+			pending = pending[i+1:]
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return fmt.Errorf("select stream failed: %w", err)
+	}
+	if line := bytes.TrimSpace(pending); len(line) > 0 {
+		return emit(line)
+	}
+	return nil
+}
+
+func selectLocally(ctx context.Context, client *s3.Client, input SelectInput, emit func(line []byte) error) error {
+	query, err := parseSelectQuery(input.Expression)
+	if err != nil {
+		return validationError([]string{fmt.Sprintf("invalid expression: %v", err)})
+	}
+	if query.limit == 0 {
+		return nil
+	}
+
+	output, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(input.Bucket),
+		Key:    aws.String(input.Key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	defer output.Body.Close()
+
+	var body io.Reader = output.Body
+	if input.Compression == "GZIP" {
+		gz, err := gzip.NewReader(output.Body)
+		if err != nil {
+			return fmt.Errorf("failed to decompress object: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	matched := 0
+	process := func(record selectRecord, all func() []byte) (bool, error) {
+		if query.where != nil && !query.where.eval(record) {
+			return true, nil
+		}
+
+		var line []byte
+		if query.columns == nil {
+			line = all()
+		} else {
+			values := make([]interface{}, len(query.columns))
+			for i, column := range query.columns {
+				values[i], _ = record.get(column)
+			}
+			line = encodeOrderedRow(query.columns, values)
+		}
+		if err := emit(line); err != nil {
+			return false, err
+		}
+
+		matched++
+		return query.limit < 0 || matched < query.limit, nil
+	}
+
+	if input.Format == "csv" {
+		return selectCSV(ctx, body, input, process)
+	}
+	return selectJSONLines(ctx, body, process)
+}
+
+type csvRecord struct {
+	columns map[string]int
+	values  []string
+}
+
+func (r csvRecord) get(column string) (interface{}, bool) {
+	index, ok := r.columns[column]
+	if !ok && strings.HasPrefix(column, "_") {
+		position, err := strconv.Atoi(column[1:])
+		index, ok = position-1, err == nil
+	}
+	if !ok || index < 0 || index >= len(r.values) {
+		return nil, false
+	}
+	return r.values[index], true
+}
+
+func selectCSV(ctx context.Context, body io.Reader, input SelectInput, process func(selectRecord, func() []byte) (bool, error)) error {
+	reader := csv.NewReader(body)
+	reader.Comma = []rune(input.Delimiter)[0]
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	columns := make(map[string]int)
+	var names []string
+	if !input.NoHeader {
+		header, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		names = header
+		for i, name := range header {
+			columns[name] = i
+		}
+	}
+
+	for ctx.Err() == nil {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		record := csvRecord{columns: columns, values: values}
+		all := func() []byte {
+			keys := make([]string, len(values))
+			row := make([]interface{}, len(values))
+			for i, value := range values {
+				if i < len(names) {
+					keys[i] = names[i]
+				} else {
+					keys[i] = "_" + strconv.Itoa(i+1)
+				}
+				row[i] = value
+			}
+			return encodeOrderedRow(keys, row)
+		}
+
+		more, err := process(record, all)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+type jsonRecord map[string]interface{}
+
+func (r jsonRecord) get(column string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(r)
+	for _, part := range strings.Split(column, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func selectJSONLines(ctx context.Context, body io.Reader, process func(selectRecord, func() []byte) (bool, error)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxSelectLineBytes)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var record jsonRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("line %d is not a JSON object: %w", lineNumber, err)
+		}
+
+		more, err := process(record, func() []byte { return append([]byte(nil), line...) })
+		if err != nil || !more {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read JSON lines: %w", err)
+	}
+	return nil
+}
+
+// encodeOrderedRow encodes a JSON object keeping the keys in the given order
+func encodeOrderedRow(keys []string, values []interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, _ := json.Marshal(key)
+		encodedValue, err := json.Marshal(values[i])
+		if err != nil {
+			encodedValue = []byte("null")
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,27 +12,52 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+const (
+	// bucketRegionTTL is how long a bucket's region is cached; buckets never move, so this can be long
+	bucketRegionTTL = time.Hour
+	// bucketListTTL is how long the full bucket listing is cached
+	bucketListTTL = 5 * time.Minute
+	// bucketRegionWorkers bounds the number of concurrent GetBucketLocation calls
+	bucketRegionWorkers = 8
+)
+
 // BucketInfo holds the information about each bucket
 type BucketInfo struct {
 	Name         string `json:"name"`
 	Region       string `json:"region,omitempty"`
 	CreationDate string `json:"creation_date"`
+	Error        string `json:"error,omitempty"`
 }
 
 // S3Service is the service struct that holds the S3 client
 type S3Service struct {
-	Client *s3.Client
+	Client    *s3.Client
+	Jobs      *JobManager
+	Snapshots *SnapshotStore
+
+	regions *ttlCache[string]
+	buckets *ttlCache[[]BucketInfo]
 }
 
 // NewS3Service initializes the S3Service
 func NewS3Service(client *s3.Client) *S3Service {
 	return &S3Service{
-		Client: client,
+		Client:  client,
+		Jobs:    NewJobManager(),
+		regions: newTTLCache[string](bucketRegionTTL),
+		buckets: newTTLCache[[]BucketInfo](bucketListTTL),
 	}
 }
 
-// ListBuckets retrieves a list of all buckets and their regions
-func (s *S3Service) ListBuckets() ([]BucketInfo, error) {
+// ListBuckets retrieves a list of all buckets and their regions.
+// Results are cached; refresh bypasses both the listing and the region cache.
+func (s *S3Service) ListBuckets(refresh bool) ([]BucketInfo, error) {
+	if !refresh {
+		if buckets, ok := s.buckets.Get("all"); ok {
+			return buckets, nil
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
@@ -42,45 +68,73 @@ func (s *S3Service) ListBuckets() ([]BucketInfo, error) {
 	}
 
 	var wg sync.WaitGroup
+	bucketCh := make(chan types.Bucket)
 	bucketsCh := make(chan BucketInfo, len(output.Buckets)) // Channel to collect results
 
-	// Loop through each bucket and fetch the region concurrently using goroutines
-	for _, bucket := range output.Buckets {
+	// Resolve regions with a fixed number of workers
+	for i := 0; i < bucketRegionWorkers; i++ {
 		wg.Add(1)
-		go func(bucket types.Bucket) {
+		go func() {
 			defer wg.Done()
-			region := s.getBucketRegion(ctx, aws.ToString(bucket.Name))
-			bucketsCh <- BucketInfo{
-				Name:         aws.ToString(bucket.Name),
-				CreationDate: bucket.CreationDate.Format(time.RFC3339),
-				Region:       region,
+			for bucket := range bucketCh {
+				info := BucketInfo{
+					Name:         aws.ToString(bucket.Name),
+					CreationDate: bucket.CreationDate.Format(time.RFC3339),
+				}
+
+				region, err := s.bucketRegion(ctx, info.Name, refresh)
+				if err != nil {
+					info.Region = "Unknown"
+					info.Error = err.Error()
+				} else {
+					info.Region = region
+				}
+				bucketsCh <- info
 			}
-		}(bucket)
+		}()
+	}
+
+	for _, bucket := range output.Buckets {
+		bucketCh <- bucket
 	}
+	close(bucketCh)
 
-	// Wait for all goroutines to finish and close the channel
-	go func() {
-		wg.Wait()
-		close(bucketsCh)
-	}()
+	wg.Wait()
+	close(bucketsCh)
 
-	var buckets []BucketInfo
+	buckets := make([]BucketInfo, 0, len(output.Buckets))
 	for bucket := range bucketsCh {
 		buckets = append(buckets, bucket)
 	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Name < buckets[j].Name
+	})
 
+	s.buckets.Set("all", buckets)
 	return buckets, nil
 }
 
-// getBucketRegion fetches the region for a bucket
-func (s *S3Service) getBucketRegion(ctx context.Context, bucketName string) string {
-	// Fetch the region from S3
+// BucketRegion returns the region a bucket lives in
+func (s *S3Service) BucketRegion(bucketName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return s.bucketRegion(ctx, bucketName, false)
+}
+
+// bucketRegion fetches the region for a bucket, consulting the cache unless refresh is set
+func (s *S3Service) bucketRegion(ctx context.Context, bucketName string, refresh bool) (string, error) {
+	if !refresh {
+		if region, ok := s.regions.Get(bucketName); ok {
+			return region, nil
+		}
+	}
+
 	locationOutput, err := s.Client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
 		Bucket: aws.String(bucketName),
 	})
 	if err != nil {
-		fmt.Printf("failed to get location for bucket %s: %v\n", bucketName, err)
-		return "Unknown"
+		return "", fmt.Errorf("failed to get location for bucket %s: %w", bucketName, err)
 	}
 
 	// Default region if no location is found
@@ -89,5 +143,21 @@ func (s *S3Service) getBucketRegion(ctx context.Context, bucketName string) stri
 		region = string(locationOutput.LocationConstraint)
 	}
 
-	return region
+	s.regions.Set(bucketName, region)
+	return region, nil
+}
+
+// clientForBucket returns an S3 client bound to the bucket's region
+func (s *S3Service) clientForBucket(bucketName string) (*s3.Client, error) {
+	region, err := s.BucketRegion(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	if region == s.Client.Options().Region {
+		return s.Client, nil
+	}
+	return s3.New(s.Client.Options(), func(o *s3.Options) {
+		o.Region = region
+	}), nil
 }
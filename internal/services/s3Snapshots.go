@@ -0,0 +1,333 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxSnapshotDiffEntries caps each list of a snapshot diff; the counts are always complete
+const maxSnapshotDiffEntries = 1000
+
+var snapshotIDPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// SnapshotInfo describes a stored bucket snapshot
+type SnapshotInfo struct {
+	ID             string `json:"id"`
+	Bucket         string `json:"bucket"`
+	Prefix         string `json:"prefix"`
+	CreatedAt      string `json:"created_at"`
+	ObjectCount    int64  `json:"object_count"`
+	TotalSizeBytes int64  `json:"total_size_bytes"`
+}
+
+// SnapshotObject is one object as recorded in a snapshot
+type SnapshotObject struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+// ModifiedObject pairs the old and new state of an object that changed between snapshots
+type ModifiedObject struct {
+	Key    string         `json:"key"`
+	Before SnapshotObject `json:"before"`
+	After  SnapshotObject `json:"after"`
+}
+
+// SnapshotDiff lists what was added, removed and modified between two snapshots
+type SnapshotDiff struct {
+	From          SnapshotInfo     `json:"from"`
+	To            SnapshotInfo     `json:"to"`
+	AddedCount    int              `json:"added_count"`
+	RemovedCount  int              `json:"removed_count"`
+	ModifiedCount int              `json:"modified_count"`
+	Truncated     bool             `json:"truncated"`
+	Added         []SnapshotObject `json:"added"`
+	Removed       []SnapshotObject `json:"removed"`
+	Modified      []ModifiedObject `json:"modified"`
+}
+
+// SnapshotStore keeps bucket snapshots on the local filesystem. Each snapshot is a JSON
+// metadata file next to a gzipped JSON-lines file of objects sorted by key.
+type SnapshotStore struct {
+	Dir string
+}
+
+// NewSnapshotStore initializes the SnapshotStore, creating its directory if needed
+func NewSnapshotStore(dir string) (*SnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create snapshot directory: %w", err)
+	}
+	return &SnapshotStore{Dir: dir}, nil
+}
+
+// List returns the stored snapshots, optionally only those of one bucket, newest first
+func (st *SnapshotStore) List(bucketName string) ([]SnapshotInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(st.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []SnapshotInfo{}
+	for _, path := range paths {
+		var info SnapshotInfo
+		data, err := os.ReadFile(path)
+		if err != nil || json.Unmarshal(data, &info) != nil {
+			continue
+		}
+		if bucketName == "" || info.Bucket == bucketName {
+			snapshots = append(snapshots, info)
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt > snapshots[j].CreatedAt
+	})
+	return snapshots, nil
+}
+
+// Get returns the metadata of a snapshot
+func (st *SnapshotStore) Get(id string) (*SnapshotInfo, error) {
+	if !snapshotIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("invalid snapshot id %q", id)
+	}
+
+	data, err := os.ReadFile(st.metadataPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("snapshot %s not found", id)
+		}
+		return nil, err
+	}
+
+	var info SnapshotInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("snapshot %s is corrupt: %w", id, err)
+	}
+	return &info, nil
+}
+
+// Delete removes a snapshot
+func (st *SnapshotStore) Delete(id string) error {
+	if _, err := st.Get(id); err != nil {
+		return err
+	}
+	if err := os.Remove(st.objectsPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(st.metadataPath(id))
+}
+
+func (st *SnapshotStore) metadataPath(id string) string {
+	return filepath.Join(st.Dir, id+".json")
+}
+
+func (st *SnapshotStore) objectsPath(id string) string {
+	return filepath.Join(st.Dir, id+".jsonl.gz")
+}
+
+// StartSnapshot records the keys, sizes and ETags under a prefix as a background job
+func (s *S3Service) StartSnapshot(bucketName, prefix string) (JobInfo, error) {
+	if s.Snapshots == nil {
+		return JobInfo{}, fmt.Errorf("snapshot storage is not configured")
+	}
+
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return JobInfo{}, err
+	}
+
+	job := s.Jobs.Start("snapshot", func(ctx context.Context, job *Job) (interface{}, error) {
+		return s.Snapshots.capture(ctx, job, client, bucketName, prefix)
+	})
+	return job, nil
+}
+
+func (st *SnapshotStore) capture(ctx context.Context, job *Job, client *s3.Client, bucketName, prefix string) (*SnapshotInfo, error) {
+	info := &SnapshotInfo{
+		ID:        newJobID(),
+		Bucket:    bucketName,
+		Prefix:    prefix,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	// Write to a temporary file so an interrupted capture never leaves a partial snapshot
+	tmp, err := os.CreateTemp(st.Dir, "capture-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	encoder := json.NewEncoder(gz)
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		// ListObjectsV2 returns keys in UTF-8 binary order, which diffing relies on
+		for _, object := range page.Contents {
+			record := SnapshotObject{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				ETag:         aws.ToString(object.ETag),
+				LastModified: formatTime(object.LastModified),
+			}
+			if err := encoder.Encode(record); err != nil {
+				return nil, fmt.Errorf("unable to write snapshot: %w", err)
+			}
+			info.ObjectCount++
+			info.TotalSizeBytes += record.Size
+		}
+
+		progress := *info
+		job.SetProgress(&progress)
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("unable to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("unable to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), st.objectsPath(info.ID)); err != nil {
+		return nil, fmt.Errorf("unable to store snapshot: %w", err)
+	}
+
+	metadata, _ := json.MarshalIndent(info, "", "  ")
+	if err := os.WriteFile(st.metadataPath(info.ID), metadata, 0o644); err != nil {
+		return nil, fmt.Errorf("unable to store snapshot metadata: %w", err)
+	}
+
+	return info, nil
+}
+
+// Diff compares two snapshots by merging their sorted object lists
+func (st *SnapshotStore) Diff(fromID, toID string) (*SnapshotDiff, error) {
+	from, err := st.Get(fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := st.Get(toID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromReader, err := st.openObjects(fromID)
+	if err != nil {
+		return nil, err
+	}
+	defer fromReader.Close()
+	toReader, err := st.openObjects(toID)
+	if err != nil {
+		return nil, err
+	}
+	defer toReader.Close()
+
+	diff := &SnapshotDiff{
+		From:     *from,
+		To:       *to,
+		Added:    []SnapshotObject{},
+		Removed:  []SnapshotObject{},
+		Modified: []ModifiedObject{},
+	}
+
+	before, beforeOK, err := fromReader.next()
+	if err != nil {
+		return nil, err
+	}
+	after, afterOK, err := toReader.next()
+	if err != nil {
+		return nil, err
+	}
+
+	for beforeOK || afterOK {
+		switch {
+		case beforeOK && (!afterOK || before.Key < after.Key):
+			diff.RemovedCount++
+			if len(diff.Removed) < maxSnapshotDiffEntries {
+				diff.Removed = append(diff.Removed, before)
+			}
+			before, beforeOK, err = fromReader.next()
+
+		case afterOK && (!beforeOK || after.Key < before.Key):
+			diff.AddedCount++
+			if len(diff.Added) < maxSnapshotDiffEntries {
+				diff.Added = append(diff.Added, after)
+			}
+			after, afterOK, err = toReader.next()
+
+		default:
+			if before.ETag != after.ETag || before.Size != after.Size {
+				diff.ModifiedCount++
+				if len(diff.Modified) < maxSnapshotDiffEntries {
+					diff.Modified = append(diff.Modified, ModifiedObject{Key: after.Key, Before: before, After: after})
+				}
+			}
+			if before, beforeOK, err = fromReader.next(); err == nil {
+				after, afterOK, err = toReader.next()
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	diff.Truncated = diff.AddedCount > len(diff.Added) || diff.RemovedCount > len(diff.Removed) || diff.ModifiedCount > len(diff.Modified)
+	return diff, nil
+}
+
+type snapshotReader struct {
+	file    *os.File
+	gz      *gzip.Reader
+	decoder *json.Decoder
+}
+
+func (st *SnapshotStore) openObjects(id string) (*snapshotReader, error) {
+	file, err := os.Open(st.objectsPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open snapshot %s: %w", id, err)
+	}
+	gz, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("snapshot %s is corrupt: %w", id, err)
+	}
+	return &snapshotReader{file: file, gz: gz, decoder: json.NewDecoder(gz)}, nil
+}
+
+func (r *snapshotReader) next() (SnapshotObject, bool, error) {
+	var object SnapshotObject
+	if err := r.decoder.Decode(&object); err != nil {
+		if err == io.EOF {
+			return object, false, nil
+		}
+		return object, false, fmt.Errorf("unable to read snapshot: %w", err)
+	}
+	return object, true, nil
+}
+
+func (r *snapshotReader) Close() error {
+	r.gz.Close()
+	return r.file.Close()
+}
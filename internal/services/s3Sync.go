@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	defaultSyncConcurrency = 8
+	maxSyncConcurrency     = 32
+)
+
+// SyncInput describes a one-way mirror of a source prefix to a destination prefix
+type SyncInput struct {
+	SourceBucket      string `json:"source_bucket"`
+	SourcePrefix      string `json:"source_prefix"`
+	DestinationBucket string `json:"destination_bucket"`
+	DestinationPrefix string `json:"destination_prefix"`
+	DeleteExtraneous  bool   `json:"delete_extraneous"`
+	Concurrency       int    `json:"concurrency"`
+	DryRun            bool   `json:"dry_run"`
+}
+
+// SyncFailure records an object that could not be copied or deleted
+type SyncFailure struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// SyncProgress is the running tally of a sync job
+type SyncProgress struct {
+	SourceObjects int           `json:"source_objects"`
+	ToCopy        int           `json:"to_copy"`
+	ToDelete      int           `json:"to_delete"`
+	Copied        int           `json:"copied"`
+	Skipped       int           `json:"skipped"`
+	Deleted       int           `json:"deleted"`
+	BytesCopied   int64         `json:"bytes_copied"`
+	Failures      []SyncFailure `json:"failures"`
+}
+
+type syncObject struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+// StartSync validates the request and starts a background job mirroring source to destination
+func (s *S3Service) StartSync(input SyncInput) (JobInfo, error) {
+	var problems []string
+	if input.SourceBucket == "" {
+		problems = append(problems, "source_bucket is required")
+	}
+	if input.DestinationBucket == "" {
+		problems = append(problems, "destination_bucket is required")
+	}
+	if input.SourceBucket == input.DestinationBucket && overlappingPrefixes(input.SourcePrefix, input.DestinationPrefix) {
+		problems = append(problems, "source and destination prefixes must not overlap within the same bucket")
+	}
+	if input.Concurrency < 0 || input.Concurrency > maxSyncConcurrency {
+		problems = append(problems, fmt.Sprintf("concurrency must be between 0 (default) and %d", maxSyncConcurrency))
+	}
+	if err := validationError(problems); err != nil {
+		return JobInfo{}, err
+	}
+	if input.Concurrency == 0 {
+		input.Concurrency = defaultSyncConcurrency
+	}
+
+	sourceClient, err := s.clientForBucket(input.SourceBucket)
+	if err != nil {
+		return JobInfo{}, err
+	}
+	destinationClient, err := s.clientForBucket(input.DestinationBucket)
+	if err != nil {
+		return JobInfo{}, err
+	}
+
+	job := s.Jobs.Start("sync", func(ctx context.Context, job *Job) (interface{}, error) {
+		return s.runSync(ctx, job, sourceClient, destinationClient, input)
+	})
+	return job, nil
+}
+
+func (s *S3Service) runSync(ctx context.Context, job *Job, sourceClient, destinationClient *s3.Client, input SyncInput) (*SyncProgress, error) {
+	progress := &SyncProgress{Failures: []SyncFailure{}}
+	var mu sync.Mutex
+
+	publish := func() {
+		snapshot := *progress
+		snapshot.Failures = append([]SyncFailure(nil), progress.Failures...)
+		job.SetProgress(&snapshot)
+	}
+
+	sourceObjects, err := listSyncObjects(ctx, sourceClient, input.SourceBucket, input.SourcePrefix)
+	if err != nil {
+		return progress, err
+	}
+	destinationObjects, err := listSyncObjects(ctx, destinationClient, input.DestinationBucket, input.DestinationPrefix)
+	if err != nil {
+		return progress, err
+	}
+
+	var toCopy []syncObject
+	for relativeKey, source := range sourceObjects {
+		if destination, ok := destinationObjects[relativeKey]; ok && syncUnchanged(source, destination) {
+			progress.Skipped++
+			continue
+		}
+		toCopy = append(toCopy, source)
+	}
+
+	var toDelete []string
+	if input.DeleteExtraneous {
+		for relativeKey := range destinationObjects {
+			if _, ok := sourceObjects[relativeKey]; !ok {
+				toDelete = append(toDelete, input.DestinationPrefix+relativeKey)
+			}
+		}
+	}
+
+	progress.SourceObjects = len(sourceObjects)
+	progress.ToCopy = len(toCopy)
+	progress.ToDelete = len(toDelete)
+	publish()
+
+	if input.DryRun {
+		return progress, nil
+	}
+
+	work := make(chan syncObject)
+	var wg sync.WaitGroup
+	for i := 0; i < input.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for object := range work {
+				destinationKey := input.DestinationPrefix + strings.TrimPrefix(object.Key, input.SourcePrefix)
+				err := copyObject(ctx, sourceClient, destinationClient, input.SourceBucket, object.Key, input.DestinationBucket, destinationKey, object.Size)
+
+				mu.Lock()
+				if err != nil {
+					progress.Failures = append(progress.Failures, SyncFailure{Key: object.Key, Error: err.Error()})
+				} else {
+					progress.Copied++
+					progress.BytesCopied += object.Size
+				}
+				publish()
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, object := range toCopy {
+		select {
+		case work <- object:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return progress, ctx.Err()
+	}
+
+	for _, key := range toDelete {
+		if ctx.Err() != nil {
+			return progress, ctx.Err()
+		}
+
+		_, err := destinationClient.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(input.DestinationBucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			progress.Failures = append(progress.Failures, SyncFailure{Key: key, Error: err.Error()})
+		} else {
+			progress.Deleted++
+		}
+		publish()
+	}
+
+	if len(progress.Failures) > 0 {
+		return progress, fmt.Errorf("%d objects failed to sync", len(progress.Failures))
+	}
+	return progress, nil
+}
+
+// listSyncObjects lists every object under prefix keyed by its path relative to prefix
+func listSyncObjects(ctx context.Context, client *s3.Client, bucketName, prefix string) (map[string]syncObject, error) {
+	objects := make(map[string]syncObject)
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects in %s: %w", bucketName, err)
+		}
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			objects[strings.TrimPrefix(key, prefix)] = syncObject{
+				Key:          key,
+				Size:         aws.ToInt64(object.Size),
+				ETag:         aws.ToString(object.ETag),
+				LastModified: aws.ToTime(object.LastModified),
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// syncUnchanged decides whether the destination copy is already up to date.
+// ETags of multipart uploads differ between otherwise identical objects, so a
+// newer destination of the same size is also treated as unchanged.
+func syncUnchanged(source, destination syncObject) bool {
+	if source.Size != destination.Size {
+		return false
+	}
+	return source.ETag == destination.ETag || !destination.LastModified.Before(source.LastModified)
+}
+
+func overlappingPrefixes(a, b string) bool {
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
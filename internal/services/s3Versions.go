@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ObjectVersion describes one version of an object, or a delete marker
+type ObjectVersion struct {
+	Key            string `json:"key"`
+	VersionID      string `json:"version_id"`
+	IsLatest       bool   `json:"is_latest"`
+	IsDeleteMarker bool   `json:"is_delete_marker"`
+	LastModified   string `json:"last_modified"`
+	Size           int64  `json:"size"`
+	ETag           string `json:"etag,omitempty"`
+	StorageClass   string `json:"storage_class,omitempty"`
+
+	lastModified time.Time
+}
+
+// ObjectVersionsPage is one page of object versions. Pass the Next markers back to continue listing.
+type ObjectVersionsPage struct {
+	Versions            []ObjectVersion `json:"versions"`
+	IsTruncated         bool            `json:"is_truncated"`
+	NextKeyMarker       string          `json:"next_key_marker,omitempty"`
+	NextVersionIDMarker string          `json:"next_version_id_marker,omitempty"`
+}
+
+// ObjectDownload is an open object body along with its headers
+type ObjectDownload struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+	VersionID     string
+}
+
+// ListObjectVersions lists object versions and delete markers under prefix, newest first per key
+func (s *S3Service) ListObjectVersions(bucketName, prefix, keyMarker, versionIDMarker string) (*ObjectVersionsPage, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	}
+	if keyMarker != "" {
+		input.KeyMarker = aws.String(keyMarker)
+		input.VersionIdMarker = aws.String(versionIDMarker)
+	}
+
+	output, err := client.ListObjectVersions(context.TODO(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list object versions: %w", err)
+	}
+
+	page := &ObjectVersionsPage{
+		Versions:            []ObjectVersion{},
+		IsTruncated:         aws.ToBool(output.IsTruncated),
+		NextKeyMarker:       aws.ToString(output.NextKeyMarker),
+		NextVersionIDMarker: aws.ToString(output.NextVersionIdMarker),
+	}
+
+	for _, version := range output.Versions {
+		page.Versions = append(page.Versions, ObjectVersion{
+			Key:          aws.ToString(version.Key),
+			VersionID:    aws.ToString(version.VersionId),
+			IsLatest:     aws.ToBool(version.IsLatest),
+			LastModified: formatTime(version.LastModified),
+			lastModified: aws.ToTime(version.LastModified),
+			Size:         aws.ToInt64(version.Size),
+			ETag:         aws.ToString(version.ETag),
+			StorageClass: string(version.StorageClass),
+		})
+	}
+	for _, marker := range output.DeleteMarkers {
+		page.Versions = append(page.Versions, ObjectVersion{
+			Key:            aws.ToString(marker.Key),
+			VersionID:      aws.ToString(marker.VersionId),
+			IsLatest:       aws.ToBool(marker.IsLatest),
+			IsDeleteMarker: true,
+			LastModified:   formatTime(marker.LastModified),
+			lastModified:   aws.ToTime(marker.LastModified),
+		})
+	}
+
+	// S3 returns versions and delete markers separately; interleave them by key and age.
+	// Timestamps can tie for versions written close together, so the latest one is put first.
+	sort.SliceStable(page.Versions, func(i, j int) bool {
+		a, b := page.Versions[i], page.Versions[j]
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		if !a.lastModified.Equal(b.lastModified) {
+			return a.lastModified.After(b.lastModified)
+		}
+		return a.IsLatest && !b.IsLatest
+	})
+
+	return page, nil
+}
+
+// GetObjectVersion opens a specific version of an object for download.
+// The caller must close the returned body.
+func (s *S3Service) GetObjectVersion(bucketName, key, versionID string) (*ObjectDownload, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
+	output, err := client.GetObject(context.TODO(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	return &ObjectDownload{
+		Body:          output.Body,
+		ContentType:   aws.ToString(output.ContentType),
+		ContentLength: aws.ToInt64(output.ContentLength),
+		VersionID:     aws.ToString(output.VersionId),
+	}, nil
+}
+
+// RestoreObjectVersion makes a previous version current again by copying it over the object.
+// Versions larger than 5GB are copied in parts. It returns the version ID of the newly created
+// current version.
+func (s *S3Service) RestoreObjectVersion(bucketName, key, versionID string) (string, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	head, err := headSourceObject(ctx, client, bucketName, key, versionID)
+	if err != nil {
+		return "", err
+	}
+	if aws.ToInt64(head.ContentLength) > maxSingleCopySize {
+		versionID, err := multipartCopyObject(ctx, client, bucketName, key, versionID, bucketName, key, head)
+		if err != nil {
+			return "", fmt.Errorf("failed to restore object version: %w", err)
+		}
+		return versionID, nil
+	}
+
+	output, err := client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(bucketName),
+		Key:        aws.String(key),
+		CopySource: aws.String(copySource(bucketName, key, versionID)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to restore object version: %w", err)
+	}
+
+	return aws.ToString(output.VersionId), nil
+}
+
+// UndeleteObject removes the delete marker that hides the latest version of an object.
+// It returns the version ID of the removed delete marker.
+func (s *S3Service) UndeleteObject(bucketName, key string) (string, error) {
+	client, err := s.clientForBucket(bucketName)
+	if err != nil {
+		return "", err
+	}
+
+	// Page through the versions under the key until its latest entry is found or the listing
+	// moves past the key, since other keys share the prefix and a key can have many versions
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(key),
+	}
+	var markerID string
+	for {
+		output, err := client.ListObjectVersions(context.TODO(), input)
+		if err != nil {
+			return "", fmt.Errorf("failed to list object versions: %w", err)
+		}
+
+		found := false
+		for _, marker := range output.DeleteMarkers {
+			if aws.ToString(marker.Key) == key && aws.ToBool(marker.IsLatest) {
+				markerID = aws.ToString(marker.VersionId)
+				found = true
+				break
+			}
+		}
+		for _, version := range output.Versions {
+			if aws.ToString(version.Key) == key && aws.ToBool(version.IsLatest) {
+				found = true
+				break
+			}
+		}
+
+		if found || !aws.ToBool(output.IsTruncated) || aws.ToString(output.NextKeyMarker) > key {
+			break
+		}
+		input.KeyMarker = output.NextKeyMarker
+		input.VersionIdMarker = output.NextVersionIdMarker
+	}
+	if markerID == "" {
+		return "", fmt.Errorf("object %s is not deleted", key)
+	}
+
+	_, err = client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		VersionId: aws.String(markerID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to remove delete marker: %w", err)
+	}
+
+	return markerID, nil
+}
+
+// copySource builds the URL-encoded CopySource value for CopyObject
+func copySource(bucketName, key, versionID string) string {
+	source := bucketName + "/" + strings.ReplaceAll(url.PathEscape(key), "%2F", "/")
+	if versionID != "" {
+		source += "?versionId=" + url.QueryEscape(versionID)
+	}
+	return source
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,622 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // embed the zone database so schedules work on hosts without one
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// schedulerTick is how often schedules are checked for due runs
+	schedulerTick = 30 * time.Second
+	// missedRunGrace is how late a run may be before it counts as missed, e.g. after a restart
+	missedRunGrace = 2 * time.Minute
+	// maxMissedRuns bounds how many missed runs are counted per check
+	maxMissedRuns = 10000
+	maxRunHistory = 1000
+)
+
+const (
+	MissedRunLatest = "run_latest" // run only the most recent missed action
+	MissedRunSkip   = "skip"       // record missed runs without acting on them
+)
+
+// ErrScheduleNotFound is returned when no schedule has the requested ID
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Schedule starts and stops a set of instances on cron expressions evaluated in a time zone
+type Schedule struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Start           string            `json:"start"` // cron expression, empty for none
+	Stop            string            `json:"stop"`  // cron expression, empty for none
+	TimeZone        string            `json:"timeZone"`
+	InstanceIDs     []string          `json:"instanceIds"`
+	TagSelector     map[string]string `json:"tagSelector"`
+	Enabled         bool              `json:"enabled"`
+	MissedRunPolicy string            `json:"missedRunPolicy"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	UpdatedAt       time.Time         `json:"updatedAt"`
+	LastCheckedAt   time.Time         `json:"lastCheckedAt"`
+	NextStart       *time.Time        `json:"nextStart,omitempty"`
+	NextStop        *time.Time        `json:"nextStop,omitempty"`
+}
+
+// ScheduleInput creates or replaces a schedule
+type ScheduleInput struct {
+	Name            string            `json:"name"`
+	Start           string            `json:"start"`
+	Stop            string            `json:"stop"`
+	TimeZone        string            `json:"timeZone"`
+	InstanceIDs     []string          `json:"instanceIds"`
+	TagSelector     map[string]string `json:"tagSelector"`
+	Enabled         *bool             `json:"enabled"` // defaults to true
+	MissedRunPolicy string            `json:"missedRunPolicy"`
+}
+
+// ScheduleRun records one execution, or one batch of missed executions, of a schedule
+type ScheduleRun struct {
+	ScheduleID   string              `json:"scheduleId"`
+	ScheduleName string              `json:"scheduleName"`
+	Action       string              `json:"action"`
+	Status       string              `json:"status"` // succeeded, partial, failed, missed or no_instances
+	ScheduledFor time.Time           `json:"scheduledFor"`
+	RanAt        time.Time           `json:"ranAt"`
+	Message      string              `json:"message,omitempty"`
+	Instances    []ScheduleRunResult `json:"instances,omitempty"`
+}
+
+// ScheduleRunResult is the outcome for one instance of a run
+type ScheduleRunResult struct {
+	InstanceID string `json:"instanceId"`
+	Error      string `json:"error,omitempty"`
+}
+
+type scheduleEvent struct {
+	schedule Schedule
+	action   string
+	at       time.Time
+}
+
+// Scheduler runs instance schedules in the background and persists them with their run history
+type Scheduler struct {
+	EC2 *EC2Service
+
+	mu        sync.Mutex
+	path      string
+	schedules map[string]*Schedule
+	history   []ScheduleRun
+}
+
+type schedulerState struct {
+	Schedules []*Schedule   `json:"schedules"`
+	History   []ScheduleRun `json:"history"`
+}
+
+// NewScheduler initializes the Scheduler, loading any schedules stored at path
+func NewScheduler(ec2Service *EC2Service, path string) (*Scheduler, error) {
+	s := &Scheduler{
+		EC2:       ec2Service,
+		path:      path,
+		schedules: make(map[string]*Schedule),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read schedules: %w", err)
+	}
+
+	var state schedulerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("unable to parse schedules: %w", err)
+	}
+	for _, schedule := range state.Schedules {
+		s.schedules[schedule.ID] = schedule
+	}
+	s.history = state.History
+	return s, nil
+}
+
+// Run checks schedules until ctx is cancelled. Runs missed while the server was down are
+// handled on the first check according to each schedule's missed-run policy.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		s.runDue(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// List returns all schedules with their next start and stop times
+func (s *Scheduler) List() []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules := make([]Schedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, withNextRuns(*schedule))
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].Name < schedules[j].Name
+	})
+	return schedules
+}
+
+// Create validates and stores a new schedule
+func (s *Scheduler) Create(input ScheduleInput) (*Schedule, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	schedule := &Schedule{ID: newJobID(), CreatedAt: now}
+	input.apply(schedule, now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules[schedule.ID] = schedule
+	if err := s.save(); err != nil {
+		delete(s.schedules, schedule.ID)
+		return nil, err
+	}
+
+	result := withNextRuns(*schedule)
+	return &result, nil
+}
+
+// Update replaces a schedule. Runs due before the update are not caught up.
+func (s *Scheduler) Update(id string, input ScheduleInput) (*Schedule, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.schedules[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrScheduleNotFound, id)
+	}
+
+	updated := *current
+	input.apply(&updated, time.Now().UTC())
+	s.schedules[id] = &updated
+	if err := s.save(); err != nil {
+		s.schedules[id] = current
+		return nil, err
+	}
+
+	result := withNextRuns(updated)
+	return &result, nil
+}
+
+// Delete removes a schedule; its run history is kept
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrScheduleNotFound, id)
+	}
+	delete(s.schedules, id)
+	if err := s.save(); err != nil {
+		s.schedules[id] = schedule
+		return err
+	}
+	return nil
+}
+
+// History returns the most recent runs first, optionally only those of one schedule
+func (s *Scheduler) History(scheduleID string, limit int) []ScheduleRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := []ScheduleRun{}
+	for i := len(s.history) - 1; i >= 0 && (limit <= 0 || len(runs) < limit); i-- {
+		if scheduleID == "" || s.history[i].ScheduleID == scheduleID {
+			runs = append(runs, s.history[i])
+		}
+	}
+	return runs
+}
+
+func (input *ScheduleInput) validate() error {
+	var problems []string
+	if strings.TrimSpace(input.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+
+	if input.Start == "" && input.Stop == "" {
+		problems = append(problems, "at least one of start or stop is required")
+	}
+	for field, expression := range map[string]string{"start": input.Start, "stop": input.Stop} {
+		if expression == "" {
+			continue
+		}
+		if _, err := cronParser.Parse(expression); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not a valid cron expression: %v", field, err))
+		}
+	}
+
+	if input.TimeZone == "" {
+		input.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(input.TimeZone); err != nil {
+		problems = append(problems, fmt.Sprintf("unknown time zone %q", input.TimeZone))
+	}
+
+	if len(input.InstanceIDs) == 0 && len(input.TagSelector) == 0 {
+		problems = append(problems, "instanceIds or tagSelector is required")
+	}
+	for key := range input.TagSelector {
+		if key == "" {
+			problems = append(problems, "tagSelector keys must not be empty")
+		}
+	}
+
+	switch input.MissedRunPolicy {
+	case "":
+		input.MissedRunPolicy = MissedRunLatest
+	case MissedRunLatest, MissedRunSkip:
+	default:
+		problems = append(problems, "missedRunPolicy must be run_latest or skip")
+	}
+
+	sort.Strings(problems)
+	return validationError(problems)
+}
+
+func (input ScheduleInput) apply(schedule *Schedule, now time.Time) {
+	schedule.Name = strings.TrimSpace(input.Name)
+	schedule.Start = input.Start
+	schedule.Stop = input.Stop
+	schedule.TimeZone = input.TimeZone
+	schedule.InstanceIDs = input.InstanceIDs
+	schedule.TagSelector = input.TagSelector
+	schedule.Enabled = input.Enabled == nil || *input.Enabled
+	schedule.MissedRunPolicy = input.MissedRunPolicy
+	schedule.UpdatedAt = now
+	schedule.LastCheckedAt = now
+}
+
+// withNextRuns fills in the next start and stop times of an enabled schedule
+func withNextRuns(schedule Schedule) Schedule {
+	schedule.NextStart, schedule.NextStop = nil, nil
+	if !schedule.Enabled {
+		return schedule
+	}
+
+	now := time.Now()
+	for _, event := range dueEvents(schedule, now, now.AddDate(1, 0, 0), 1) {
+		at := event.at
+		if event.action == "start" && schedule.NextStart == nil {
+			schedule.NextStart = &at
+		} else if event.action == "stop" && schedule.NextStop == nil {
+			schedule.NextStop = &at
+		}
+	}
+	return schedule
+}
+
+// dueEvents lists up to limit runs per action in (after, until], oldest first
+func dueEvents(schedule Schedule, after, until time.Time, limit int) []scheduleEvent {
+	location, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return nil
+	}
+
+	var events []scheduleEvent
+	for action, expression := range map[string]string{"start": schedule.Start, "stop": schedule.Stop} {
+		if expression == "" {
+			continue
+		}
+		parsed, err := cronParser.Parse(expression)
+		if err != nil {
+			continue
+		}
+
+		next := after.In(location)
+		for count := 0; count < limit; count++ {
+			next = parsed.Next(next)
+			if next.IsZero() || next.After(until) {
+				break
+			}
+			events = append(events, scheduleEvent{schedule: schedule, action: action, at: next})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].at.Before(events[j].at)
+	})
+	return events
+}
+
+// latestDueEvent returns the most recent run of any action in (after, until]. It is found
+// directly rather than from dueEvents, whose per-action cap counts from the oldest run.
+func latestDueEvent(schedule Schedule, after, until time.Time) (scheduleEvent, bool) {
+	location, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return scheduleEvent{}, false
+	}
+
+	var latest scheduleEvent
+	found := false
+	for action, expression := range map[string]string{"start": schedule.Start, "stop": schedule.Stop} {
+		if expression == "" {
+			continue
+		}
+		parsed, err := cronParser.Parse(expression)
+		if err != nil {
+			continue
+		}
+
+		// cron can only step forward, so widen a window back from until until it holds a run,
+		// then walk forward through that window to its last run
+		for window := time.Minute; ; window *= 2 {
+			from := until.Add(-window)
+			if !from.After(after) {
+				from = after
+			}
+
+			var last time.Time
+			for next := parsed.Next(from.In(location)); !next.IsZero() && !next.After(until); next = parsed.Next(next) {
+				last = next
+			}
+			if !last.IsZero() {
+				if !found || last.After(latest.at) {
+					latest = scheduleEvent{schedule: schedule, action: action, at: last}
+					found = true
+				}
+				break
+			}
+			if from.Equal(after) {
+				break
+			}
+		}
+	}
+	return latest, found
+}
+
+// runDue executes the runs that fell due since each schedule was last checked
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	var toRun []scheduleEvent
+	for _, schedule := range s.schedules {
+		if !schedule.Enabled {
+			schedule.LastCheckedAt = now
+			continue
+		}
+
+		after := schedule.LastCheckedAt
+		events := dueEvents(*schedule, after, now, maxMissedRuns)
+		schedule.LastCheckedAt = now
+		if len(events) == 0 {
+			continue
+		}
+
+		var missed []scheduleEvent
+		for _, event := range events {
+			if now.Sub(event.at) > missedRunGrace {
+				missed = append(missed, event)
+			} else {
+				toRun = append(toRun, event)
+			}
+		}
+		if len(missed) == 0 {
+			continue
+		}
+
+		// When catching up, the most recent action decides the state the instances should be in
+		if schedule.MissedRunPolicy == MissedRunLatest && len(missed) == len(events) {
+			if latest, ok := latestDueEvent(*schedule, after, now); ok {
+				kept := missed[:0]
+				for _, event := range missed {
+					if event.action != latest.action || !event.at.Equal(latest.at) {
+						kept = append(kept, event)
+					}
+				}
+				missed = kept
+				toRun = append(toRun, latest)
+			}
+		}
+		if len(missed) > 0 {
+			s.record(ScheduleRun{
+				ScheduleID:   schedule.ID,
+				ScheduleName: schedule.Name,
+				Action:       missedActions(missed),
+				Status:       "missed",
+				ScheduledFor: missed[0].at,
+				RanAt:        now,
+				Message:      fmt.Sprintf("%d run(s) missed between %s and %s", len(missed), missed[0].at.Format(time.RFC3339), missed[len(missed)-1].at.Format(time.RFC3339)),
+			})
+		}
+	}
+	if err := s.save(); err != nil {
+		log.Printf("unable to save schedules: %v", err)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(toRun, func(i, j int) bool {
+		return toRun[i].at.Before(toRun[j].at)
+	})
+	for _, event := range toRun {
+		run := s.execute(event)
+
+		s.mu.Lock()
+		s.record(run)
+		if err := s.save(); err != nil {
+			log.Printf("unable to save schedule history: %v", err)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func missedActions(events []scheduleEvent) string {
+	actions := make(map[string]bool)
+	for _, event := range events {
+		actions[event.action] = true
+	}
+	if len(actions) > 1 {
+		return "start,stop"
+	}
+	return events[0].action
+}
+
+// execute starts or stops the schedule's instances
+func (s *Scheduler) execute(event scheduleEvent) ScheduleRun {
+	run := ScheduleRun{
+		ScheduleID:   event.schedule.ID,
+		ScheduleName: event.schedule.Name,
+		Action:       event.action,
+		ScheduledFor: event.at,
+		RanAt:        time.Now(),
+	}
+
+	instanceIDs, err := s.resolveInstances(event.schedule)
+	if err != nil {
+		run.Status = "failed"
+		run.Message = err.Error()
+		return run
+	}
+	if len(instanceIDs) == 0 {
+		run.Status = "no_instances"
+		return run
+	}
+
+	failures := 0
+	for _, instanceID := range instanceIDs {
+		result := ScheduleRunResult{InstanceID: instanceID}
+		if event.action == "start" {
+			_, err = s.EC2.StartInstanceById(instanceID)
+		} else {
+			_, err = s.EC2.StopInstanceById(instanceID)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			failures++
+		}
+		run.Instances = append(run.Instances, result)
+	}
+
+	switch failures {
+	case 0:
+		run.Status = "succeeded"
+	case len(instanceIDs):
+		run.Status = "failed"
+	default:
+		run.Status = "partial"
+	}
+	return run
+}
+
+// resolveInstances combines the schedule's explicit instance IDs with those matching its tag selector
+func (s *Scheduler) resolveInstances(schedule Schedule) ([]string, error) {
+	seen := make(map[string]bool)
+	var instanceIDs []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			instanceIDs = append(instanceIDs, id)
+		}
+	}
+
+	for _, id := range schedule.InstanceIDs {
+		add(id)
+	}
+	if len(schedule.TagSelector) > 0 {
+		tagged, err := s.EC2.FindInstancesByTags(schedule.TagSelector)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range tagged {
+			add(id)
+		}
+	}
+	return instanceIDs, nil
+}
+
+// FindInstancesByTags returns the IDs of non-terminated instances that carry all of the given tags
+func (s *EC2Service) FindInstancesByTags(tags map[string]string) ([]string, error) {
+	filters := []types.Filter{
+		{Name: aws.String("instance-state-name"), Values: []string{"pending", "running", "stopping", "stopped"}},
+	}
+	for _, key := range sortedKeys(tags) {
+		filters = append(filters, types.Filter{Name: aws.String("tag:" + key), Values: []string{tags[key]}})
+	}
+
+	var instanceIDs []string
+	paginator := ec2.NewDescribeInstancesPaginator(s.Client, &ec2.DescribeInstancesInput{Filters: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instances: %w", err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+			}
+		}
+	}
+	return instanceIDs, nil
+}
+
+// record appends a run to the history, dropping the oldest entries beyond the cap. Callers hold s.mu.
+func (s *Scheduler) record(run ScheduleRun) {
+	s.history = append(s.history, run)
+	if len(s.history) > maxRunHistory {
+		s.history = s.history[len(s.history)-maxRunHistory:]
+	}
+}
+
+// save writes the schedules and history atomically. Callers hold s.mu.
+func (s *Scheduler) save() error {
+	state := schedulerState{Schedules: make([]*Schedule, 0, len(s.schedules)), History: s.history}
+	for _, schedule := range s.schedules {
+		stored := *schedule
+		stored.NextStart, stored.NextStop = nil, nil
+		state.Schedules = append(state.Schedules, &stored)
+	}
+	sort.Slice(state.Schedules, func(i, j int) bool {
+		return state.Schedules[i].ID < state.Schedules[j].ID
+	})
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("unable to save schedules: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("unable to save schedules: %w", err)
+	}
+	return nil
+}
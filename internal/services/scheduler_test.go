@@ -0,0 +1,247 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestScheduler returns a scheduler that saves to a temporary file. Its schedules target
+// no instances, so runs finish as no_instances without calling AWS.
+func newTestScheduler(t *testing.T, schedules ...*Schedule) *Scheduler {
+	t.Helper()
+	s, err := NewScheduler(nil, filepath.Join(t.TempDir(), "schedules.json"))
+	if err != nil {
+		t.Fatalf("NewScheduler returned %v", err)
+	}
+	for _, schedule := range schedules {
+		s.schedules[schedule.ID] = schedule
+	}
+	return s
+}
+
+// splitHistory separates executed runs from the record of missed runs
+func splitHistory(history []ScheduleRun) (executed []ScheduleRun, missed []ScheduleRun) {
+	for _, run := range history {
+		if run.Status == "missed" {
+			missed = append(missed, run)
+		} else {
+			executed = append(executed, run)
+		}
+	}
+	return executed, missed
+}
+
+func TestRunDueCatchUp(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 30, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		start, stop string
+		timeZone    string
+		policy      string
+		lastChecked time.Time
+		now         time.Time
+		wantRuns    []string // action@RFC3339 in UTC, in execution order
+		wantMissed  int      // missed runs recorded, 0 for no missed record
+	}{
+		{
+			name:  "restart catches up the latest action",
+			start: "0 8 * * *", stop: "0 18 * * *", policy: MissedRunLatest,
+			lastChecked: now.AddDate(0, 0, -3), now: now,
+			wantRuns:   []string{"start@2026-10-19T08:00:00Z"},
+			wantMissed: 5,
+		},
+		{
+			name:  "restart with skip only records missed runs",
+			start: "0 8 * * *", stop: "0 18 * * *", policy: MissedRunSkip,
+			lastChecked: now.AddDate(0, 0, -3), now: now,
+			wantMissed: 6,
+		},
+		{
+			name:  "run within the grace period is executed",
+			start: "* * * * *", policy: MissedRunSkip,
+			lastChecked: now.Add(-time.Minute), now: now,
+			wantRuns: []string{"start@2026-10-19T12:00:00Z"},
+		},
+		{
+			name:  "on-time run takes precedence over missed ones",
+			start: "0 12 * * *", stop: "0 8 * * *", policy: MissedRunLatest,
+			lastChecked: now.Add(-5 * time.Hour), now: now,
+			wantRuns:   []string{"start@2026-10-19T12:00:00Z"},
+			wantMissed: 1,
+		},
+		{
+			name:  "nothing due",
+			start: "0 8 * * *", policy: MissedRunLatest,
+			lastChecked: now.Add(-time.Hour), now: now,
+		},
+		{
+			name:  "more than maxMissedRuns still runs the latest action",
+			start: "0 * * * *", policy: MissedRunLatest,
+			lastChecked: now.AddDate(0, 0, -500), now: now.Add(29 * time.Minute),
+			wantRuns:   []string{"start@2026-10-19T12:00:00Z"},
+			wantMissed: maxMissedRuns,
+		},
+		{
+			name:  "more than maxMissedRuns with skip",
+			start: "0 * * * *", policy: MissedRunSkip,
+			lastChecked: now.AddDate(0, 0, -500), now: now.Add(29 * time.Minute),
+			wantMissed: maxMissedRuns,
+		},
+		{
+			name: "repeated hour when DST ends runs the later occurrence",
+			stop: "30 1 * * *", timeZone: "America/New_York", policy: MissedRunLatest,
+			lastChecked: time.Date(2026, 10, 31, 16, 0, 0, 0, time.UTC), now: time.Date(2026, 11, 1, 6, 45, 0, 0, time.UTC),
+			wantRuns:   []string{"stop@2026-11-01T06:30:00Z"},
+			wantMissed: 1,
+		},
+		{
+			name: "repeated hour when DST ends counts both occurrences",
+			stop: "30 1 * * *", timeZone: "America/New_York", policy: MissedRunSkip,
+			lastChecked: time.Date(2026, 10, 31, 16, 0, 0, 0, time.UTC), now: time.Date(2026, 11, 1, 6, 45, 0, 0, time.UTC),
+			wantMissed: 2,
+		},
+		{
+			name:  "skipped hour when DST starts has no run",
+			start: "30 2 * * *", timeZone: "America/New_York", policy: MissedRunLatest,
+			lastChecked: time.Date(2026, 3, 7, 17, 0, 0, 0, time.UTC), now: time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC),
+			wantRuns: []string{"start@2026-03-09T06:30:00Z"},
+		},
+		{
+			name:  "local time is used across the DST change",
+			start: "0 9 * * *", timeZone: "Europe/Berlin", policy: MissedRunSkip,
+			lastChecked: time.Date(2026, 10, 24, 12, 0, 0, 0, time.UTC), now: time.Date(2026, 10, 26, 12, 0, 0, 0, time.UTC),
+			wantMissed: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeZone := tt.timeZone
+			if timeZone == "" {
+				timeZone = "UTC"
+			}
+			schedule := &Schedule{
+				ID: "s1", Name: "test", Start: tt.start, Stop: tt.stop, TimeZone: timeZone,
+				Enabled: true, MissedRunPolicy: tt.policy, LastCheckedAt: tt.lastChecked,
+			}
+			s := newTestScheduler(t, schedule)
+
+			s.runDue(tt.now)
+
+			executed, missed := splitHistory(s.history)
+			var runs []string
+			for _, run := range executed {
+				if run.Status != "no_instances" {
+					t.Errorf("run %s@%s has status %s, want no_instances", run.Action, run.ScheduledFor, run.Status)
+				}
+				runs = append(runs, run.Action+"@"+run.ScheduledFor.UTC().Format(time.RFC3339))
+			}
+			if strings.Join(runs, ",") != strings.Join(tt.wantRuns, ",") {
+				t.Errorf("runs = %v, want %v", runs, tt.wantRuns)
+			}
+
+			switch {
+			case tt.wantMissed == 0 && len(missed) > 0:
+				t.Errorf("missed = %+v, want no missed record", missed)
+			case tt.wantMissed > 0 && len(missed) != 1:
+				t.Errorf("got %d missed records, want 1", len(missed))
+			case tt.wantMissed > 0 && !strings.HasPrefix(missed[0].Message, fmt.Sprintf("%d run(s) missed", tt.wantMissed)):
+				t.Errorf("missed message = %q, want %d run(s) missed", missed[0].Message, tt.wantMissed)
+			}
+
+			if !s.schedules["s1"].LastCheckedAt.Equal(tt.now) {
+				t.Errorf("LastCheckedAt = %s, want %s", s.schedules["s1"].LastCheckedAt, tt.now)
+			}
+		})
+	}
+}
+
+func TestRunDueDisabledThenEnabled(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 30, 0, time.UTC)
+	schedule := &Schedule{
+		ID: "s1", Name: "test", Start: "0 * * * *", TimeZone: "UTC",
+		Enabled: false, MissedRunPolicy: MissedRunLatest, LastCheckedAt: now.AddDate(0, 0, -2),
+	}
+	s := newTestScheduler(t, schedule)
+
+	// While disabled nothing runs or is recorded missed, but the check time still advances
+	s.runDue(now)
+	if len(s.history) != 0 {
+		t.Fatalf("history = %+v, want nothing while disabled", s.history)
+	}
+	if !schedule.LastCheckedAt.Equal(now) {
+		t.Fatalf("LastCheckedAt = %s, want %s", schedule.LastCheckedAt, now)
+	}
+
+	// Once enabled, runs from the disabled period are not caught up
+	schedule.Enabled = true
+	later := now.Add(time.Hour + 10*time.Second)
+	s.runDue(later)
+
+	executed, missed := splitHistory(s.history)
+	if len(missed) != 0 {
+		t.Errorf("missed = %+v, want none", missed)
+	}
+	if len(executed) != 1 || !executed[0].ScheduledFor.Equal(time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("executed = %+v, want only the 13:00 run", executed)
+	}
+}
+
+func TestLatestDueEvent(t *testing.T) {
+	until := time.Date(2026, 10, 19, 12, 30, 30, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		start, stop string
+		after       time.Time
+		wantAction  string
+		wantAt      time.Time
+		wantFound   bool
+	}{
+		{
+			name:  "frequent runs",
+			start: "*/5 * * * *", stop: "0 9 * * 1",
+			after:      until.AddDate(0, -2, 0),
+			wantAction: "start", wantAt: time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC), wantFound: true,
+		},
+		{
+			name:  "later action wins",
+			start: "0 8 1 1 *", stop: "0 9 * * 1",
+			after:      until.AddDate(-2, 0, 0),
+			wantAction: "stop", wantAt: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC), wantFound: true,
+		},
+		{
+			name:       "sparse run found by widening the window",
+			start:      "0 8 1 1 *",
+			after:      until.AddDate(-2, 0, 0),
+			wantAction: "start", wantAt: time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC), wantFound: true,
+		},
+		{
+			name:  "run exactly at until is included",
+			start: "30 12 * * *",
+			after: until.Add(-time.Hour), wantAction: "start", wantAt: time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC), wantFound: true,
+		},
+		{
+			name:  "no run in the range",
+			start: "0 8 1 1 *", stop: "0 9 * * 1",
+			after: until.Add(-time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := Schedule{Start: tt.start, Stop: tt.stop, TimeZone: "UTC"}
+			event, found := latestDueEvent(schedule, tt.after, until)
+			if found != tt.wantFound {
+				t.Fatalf("found = %v, want %v", found, tt.wantFound)
+			}
+			if found && (event.action != tt.wantAction || !event.at.Equal(tt.wantAt)) {
+				t.Errorf("latest = %s@%s, want %s@%s", event.action, event.at, tt.wantAction, tt.wantAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,471 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var comparisonOperators = map[string]bool{"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// maxSelectNesting bounds how deeply parentheses and NOT may nest in a WHERE clause
+const maxSelectNesting = 64
+
+// selectQuery is a parsed subset of the S3 Select SQL dialect:
+//
+//	SELECT * | col[, col...] FROM s3object [alias] [WHERE cond] [LIMIT n]
+//
+// where cond combines comparisons (=, !=, <>, <, <=, >, >=), LIKE and IS [NOT] NULL
+// with AND, OR, NOT and parentheses. Columns are names, dotted JSON paths or _N positions.
+type selectQuery struct {
+	columns []string // nil means *
+	where   selectCondition
+	limit   int // -1 means no limit
+}
+
+// selectRecord is one input row the query is evaluated against
+type selectRecord interface {
+	get(column string) (interface{}, bool)
+}
+
+type selectCondition interface {
+	eval(record selectRecord) bool
+}
+
+type selectOperand struct {
+	column  string
+	literal interface{}
+}
+
+func (o selectOperand) value(record selectRecord) (interface{}, bool) {
+	if o.column != "" {
+		return record.get(o.column)
+	}
+	return o.literal, true
+}
+
+type comparison struct {
+	left, right selectOperand
+	op          string
+}
+
+func (c comparison) eval(record selectRecord) bool {
+	left, ok := c.left.value(record)
+	if !ok || left == nil {
+		return false
+	}
+	right, ok := c.right.value(record)
+	if !ok || right == nil {
+		return false
+	}
+
+	var order int
+	leftNumber, leftIsNumber := toNumber(left)
+	rightNumber, rightIsNumber := toNumber(right)
+	if leftIsNumber && rightIsNumber {
+		switch {
+		case leftNumber < rightNumber:
+			order = -1
+		case leftNumber > rightNumber:
+			order = 1
+		}
+	} else {
+		order = strings.Compare(fmt.Sprint(left), fmt.Sprint(right))
+	}
+
+	switch c.op {
+	case "=":
+		return order == 0
+	case "!=", "<>":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+	return false
+}
+
+type likeCondition struct {
+	operand selectOperand
+	pattern *regexp.Regexp
+}
+
+func (l likeCondition) eval(record selectRecord) bool {
+	value, ok := l.operand.value(record)
+	return ok && value != nil && l.pattern.MatchString(fmt.Sprint(value))
+}
+
+type nullCondition struct {
+	operand selectOperand
+	negate  bool
+}
+
+func (n nullCondition) eval(record selectRecord) bool {
+	value, ok := n.operand.value(record)
+	isNull := !ok || value == nil || value == ""
+	return isNull != n.negate
+}
+
+type logicalCondition struct {
+	op          string
+	left, right selectCondition
+}
+
+func (l logicalCondition) eval(record selectRecord) bool {
+	if l.op == "AND" {
+		return l.left.eval(record) && l.right.eval(record)
+	}
+	return l.left.eval(record) || l.right.eval(record)
+}
+
+type notCondition struct {
+	inner selectCondition
+}
+
+func (n notCondition) eval(record selectRecord) bool {
+	return !n.inner.eval(record)
+}
+
+type selectToken struct {
+	kind  string // ident, string, number, op, eof
+	value string
+}
+
+type selectParser struct {
+	tokens []selectToken
+	pos    int
+	alias  string
+	depth  int
+}
+
+// parseSelectQuery parses a query for the local select engine
+func parseSelectQuery(expression string) (*selectQuery, error) {
+	tokens, err := tokenizeSelect(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &selectParser{tokens: tokens}
+	query := &selectQuery{limit: -1}
+
+	if !p.keyword("SELECT") {
+		return nil, fmt.Errorf("query must start with SELECT")
+	}
+
+	if p.peek().value == "*" {
+		p.pos++
+	} else {
+		for {
+			token := p.next()
+			if token.kind != "ident" || isSelectKeyword(token.value) {
+				return nil, fmt.Errorf("expected a column name, got %q", token.value)
+			}
+			query.columns = append(query.columns, token.value)
+			if p.peek().value != "," {
+				break
+			}
+			p.pos++
+		}
+	}
+
+	if !p.keyword("FROM") {
+		return nil, fmt.Errorf("expected FROM")
+	}
+	if source := p.next(); source.kind != "ident" || !strings.HasPrefix(strings.ToLower(source.value), "s3object") {
+		return nil, fmt.Errorf("only FROM s3object is supported")
+	}
+	if token := p.peek(); token.kind == "ident" && !isSelectKeyword(token.value) {
+		p.alias = token.value
+		p.pos++
+	}
+
+	for i, column := range query.columns {
+		query.columns[i] = p.stripAlias(column)
+	}
+
+	if p.keyword("WHERE") {
+		query.where, err = p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.keyword("LIMIT") {
+		token := p.next()
+		limit, err := strconv.Atoi(token.value)
+		if token.kind != "number" || err != nil || limit < 0 {
+			return nil, fmt.Errorf("LIMIT must be a non-negative integer")
+		}
+		query.limit = limit
+	}
+
+	if token := p.peek(); token.kind != "eof" {
+		return nil, fmt.Errorf("unexpected %q", token.value)
+	}
+	return query, nil
+}
+
+func (p *selectParser) parseOr() (selectCondition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalCondition{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *selectParser) parseAnd() (selectCondition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalCondition{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *selectParser) parseNot() (selectCondition, error) {
+	if p.keyword("NOT") {
+		defer func() { p.depth-- }()
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{inner: inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *selectParser) parsePrimary() (selectCondition, error) {
+	if p.peek().value == "(" {
+		p.pos++
+		defer func() { p.depth-- }()
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().value != ")" {
+			return nil, fmt.Errorf("expected )")
+		}
+		return inner, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.keyword("LIKE"):
+		token := p.next()
+		if token.kind != "string" {
+			return nil, fmt.Errorf("LIKE expects a string pattern")
+		}
+		return likeCondition{operand: left, pattern: likePattern(token.value)}, nil
+	case p.keyword("IS"):
+		negate := p.keyword("NOT")
+		if !p.keyword("NULL") {
+			return nil, fmt.Errorf("expected NULL after IS")
+		}
+		return nullCondition{operand: left, negate: negate}, nil
+	}
+
+	op := p.next()
+	if op.kind != "op" || !comparisonOperators[op.value] {
+		return nil, fmt.Errorf("expected a comparison operator, got %q", op.value)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return comparison{left: left, right: right, op: op.value}, nil
+}
+
+func (p *selectParser) parseOperand() (selectOperand, error) {
+	token := p.next()
+	switch token.kind {
+	case "ident":
+		if isSelectKeyword(token.value) {
+			return selectOperand{}, fmt.Errorf("unexpected %s", token.value)
+		}
+		return selectOperand{column: p.stripAlias(token.value)}, nil
+	case "string":
+		return selectOperand{literal: token.value}, nil
+	case "number":
+		number, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return selectOperand{}, fmt.Errorf("invalid number %q", token.value)
+		}
+		return selectOperand{literal: number}, nil
+	}
+	return selectOperand{}, fmt.Errorf("expected a column or value, got %q", token.value)
+}
+
+// enter tracks the nesting of the condition being parsed; the caller must decrement depth when done
+func (p *selectParser) enter() error {
+	p.depth++
+	if p.depth > maxSelectNesting {
+		return fmt.Errorf("condition nested more than %d levels deep", maxSelectNesting)
+	}
+	return nil
+}
+
+func (p *selectParser) stripAlias(column string) string {
+	if p.alias != "" && strings.HasPrefix(column, p.alias+".") {
+		return strings.TrimPrefix(column, p.alias+".")
+	}
+	return column
+}
+
+func (p *selectParser) peek() selectToken {
+	return p.tokens[p.pos]
+}
+
+func (p *selectParser) next() selectToken {
+	token := p.tokens[p.pos]
+	if token.kind != "eof" {
+		p.pos++
+	}
+	return token
+}
+
+// keyword consumes the next token if it is the given keyword
+func (p *selectParser) keyword(word string) bool {
+	token := p.peek()
+	if token.kind == "ident" && strings.EqualFold(token.value, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func isSelectKeyword(value string) bool {
+	switch strings.ToUpper(value) {
+	case "SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "LIKE", "IS", "NULL", "LIMIT":
+		return true
+	}
+	return false
+}
+
+func tokenizeSelect(expression string) ([]selectToken, error) {
+	var tokens []selectToken
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			var value strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string literal")
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						value.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, selectToken{"string", value.String()})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, selectToken{"number", string(runes[start:i])})
+		case r == '"':
+			// Quoted identifier
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated quoted identifier")
+			}
+			tokens = append(tokens, selectToken{"ident", string(runes[i+1 : end])})
+			i = end + 1
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.' || runes[i] == '[' || runes[i] == ']' || runes[i] == '*') {
+				i++
+			}
+			tokens = append(tokens, selectToken{"ident", string(runes[start:i])})
+		case strings.ContainsRune("(),*", r):
+			tokens = append(tokens, selectToken{"op", string(r)})
+			i++
+		case strings.ContainsRune("=<>!", r):
+			op := string(r)
+			if i+1 < len(runes) && strings.ContainsRune("=>", runes[i+1]) {
+				op += string(runes[i+1])
+			}
+			tokens = append(tokens, selectToken{"op", op})
+			i += len(op)
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	return append(tokens, selectToken{kind: "eof"}), nil
+}
+
+// likePattern converts a SQL LIKE pattern into an anchored regular expression
+func likePattern(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile("(?s)" + expr.String())
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
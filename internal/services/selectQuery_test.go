@@ -0,0 +1,176 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeSelect(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       []selectToken
+		wantErr    bool
+	}{
+		{
+			name:       "string with escaped quote",
+			expression: `'it''s'`,
+			want:       []selectToken{{"string", "it's"}, {kind: "eof"}},
+		},
+		{
+			name:       "quoted identifier keeps spaces and case",
+			expression: `"First Name" = 'x'`,
+			want:       []selectToken{{"ident", "First Name"}, {"op", "="}, {"string", "x"}, {kind: "eof"}},
+		},
+		{
+			name:       "two character operators",
+			expression: "a<>1 AND b>=-2.5",
+			want: []selectToken{
+				{"ident", "a"}, {"op", "<>"}, {"number", "1"},
+				{"ident", "AND"},
+				{"ident", "b"}, {"op", ">="}, {"number", "-2.5"},
+				{kind: "eof"},
+			},
+		},
+		{
+			name:       "dotted and positional columns",
+			expression: "s.user.name, _2",
+			want:       []selectToken{{"ident", "s.user.name"}, {"op", ","}, {"ident", "_2"}, {kind: "eof"}},
+		},
+		{name: "unterminated string", expression: "'abc", wantErr: true},
+		{name: "unterminated quoted identifier", expression: `"abc`, wantErr: true},
+		{name: "unexpected character", expression: "a ; b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tokenizeSelect(tt.expression)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("tokenizeSelect(%q) = %v, want an error", tt.expression, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tokenizeSelect(%q) returned %v", tt.expression, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenizeSelect(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSelectQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		expression  string
+		wantColumns []string
+		wantLimit   int
+		wantErr     bool
+	}{
+		{name: "star", expression: "SELECT * FROM s3object", wantLimit: -1},
+		{name: "columns with alias", expression: "select s.name, s.age from S3Object s limit 5", wantColumns: []string{"name", "age"}, wantLimit: 5},
+		{name: "limit zero", expression: "SELECT * FROM s3object LIMIT 0", wantLimit: 0},
+		{name: "lowercase keywords and where", expression: "select * from s3object where a = 1 and not b is null", wantLimit: -1},
+		{name: "missing SELECT", expression: "* FROM s3object", wantErr: true},
+		{name: "missing FROM", expression: "SELECT *", wantErr: true},
+		{name: "other source", expression: "SELECT * FROM users", wantErr: true},
+		{name: "keyword as column", expression: "SELECT FROM FROM s3object", wantErr: true},
+		{name: "trailing comma", expression: "SELECT a, FROM s3object", wantErr: true},
+		{name: "empty where", expression: "SELECT * FROM s3object WHERE", wantErr: true},
+		{name: "unbalanced parenthesis", expression: "SELECT * FROM s3object WHERE (a = 1", wantErr: true},
+		{name: "missing operator", expression: "SELECT * FROM s3object WHERE a 1", wantErr: true},
+		{name: "unknown operator", expression: "SELECT * FROM s3object WHERE a => 1", wantErr: true},
+		{name: "dangling AND", expression: "SELECT * FROM s3object WHERE a = 1 AND", wantErr: true},
+		{name: "LIKE without string", expression: "SELECT * FROM s3object WHERE a LIKE 1", wantErr: true},
+		{name: "IS without NULL", expression: "SELECT * FROM s3object WHERE a IS 1", wantErr: true},
+		{name: "invalid number", expression: "SELECT * FROM s3object WHERE a = 1.2.3", wantErr: true},
+		{name: "negative limit", expression: "SELECT * FROM s3object LIMIT -1", wantErr: true},
+		{name: "non-numeric limit", expression: "SELECT * FROM s3object LIMIT ten", wantErr: true},
+		{name: "trailing tokens", expression: "SELECT * FROM s3object LIMIT 1 2", wantErr: true},
+		{name: "empty", expression: "", wantErr: true},
+		{name: "nesting at the limit", expression: "SELECT * FROM s3object WHERE " + strings.Repeat("(", maxSelectNesting) + "a = 1" + strings.Repeat(")", maxSelectNesting), wantLimit: -1},
+		{name: "parentheses nested too deeply", expression: "SELECT * FROM s3object WHERE " + strings.Repeat("(", 3000000), wantErr: true},
+		{name: "NOT nested too deeply", expression: "SELECT * FROM s3object WHERE " + strings.Repeat("NOT ", maxSelectNesting+1) + "a = 1", wantErr: true},
+		{name: "mixed nesting too deep", expression: "SELECT * FROM s3object WHERE " + strings.Repeat("NOT (", maxSelectNesting/2+1) + "a = 1" + strings.Repeat(")", maxSelectNesting/2+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := parseSelectQuery(tt.expression)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSelectQuery(%q) succeeded, want an error", tt.expression)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSelectQuery(%q) returned %v", tt.expression, err)
+			}
+			if !reflect.DeepEqual(query.columns, tt.wantColumns) {
+				t.Errorf("columns = %v, want %v", query.columns, tt.wantColumns)
+			}
+			if query.limit != tt.wantLimit {
+				t.Errorf("limit = %d, want %d", query.limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestSelectConditionEval(t *testing.T) {
+	record := jsonRecord{
+		"name":   "O'Brien",
+		"age":    float64(42),
+		"score":  "9.5",
+		"city":   "",
+		"team":   nil,
+		"active": "yes",
+		"user":   map[string]interface{}{"role": "admin"},
+	}
+
+	tests := []struct {
+		name  string
+		where string
+		want  bool
+	}{
+		{name: "escaped quote in literal", where: "name = 'O''Brien'", want: true},
+		{name: "numeric comparison of number", where: "age > 9", want: true},
+		{name: "numeric comparison of numeric string", where: "score < 10", want: true},
+		{name: "string comparison when not numeric", where: "name < 'P'", want: true},
+		{name: "not equal", where: "age <> 42", want: false},
+		{name: "bang equal", where: "age != 41", want: true},
+		{name: "dotted path", where: "user.role = 'admin'", want: true},
+		{name: "quoted identifier", where: `"age" >= 42`, want: true},
+		{name: "AND binds tighter than OR", where: "age = 1 AND name = 'x' OR active = 'yes'", want: true},
+		{name: "OR inside AND", where: "age = 1 AND (name = 'x' OR active = 'yes')", want: false},
+		{name: "NOT binds tighter than AND", where: "NOT age = 1 AND active = 'yes'", want: true},
+		{name: "NOT of a group", where: "NOT (age = 42 OR age = 1)", want: false},
+		{name: "LIKE with percent", where: "name LIKE 'O%n'", want: true},
+		{name: "LIKE with underscore", where: "name LIKE 'O_Brien'", want: true},
+		{name: "LIKE is anchored", where: "name LIKE 'Bri%'", want: false},
+		{name: "LIKE treats regexp characters literally", where: "score LIKE '9_5'", want: true},
+		{name: "missing field is null", where: "missing IS NULL", want: true},
+		{name: "null field is null", where: "team IS NULL", want: true},
+		{name: "empty field is null", where: "city IS NULL", want: true},
+		{name: "present field is not null", where: "age IS NOT NULL", want: true},
+		{name: "missing field never compares equal", where: "missing = ''", want: false},
+		{name: "missing field never compares unequal", where: "missing != 'x'", want: false},
+		{name: "null field never compares", where: "team != 'x'", want: false},
+		{name: "NOT of a missing comparison", where: "NOT missing = 'x'", want: true},
+		{name: "missing field in LIKE", where: "missing LIKE '%'", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := parseSelectQuery("SELECT * FROM s3object WHERE " + tt.where)
+			if err != nil {
+				t.Fatalf("parseSelectQuery(%q) returned %v", tt.where, err)
+			}
+			if got := query.where.eval(record); got != tt.want {
+				t.Errorf("eval(%q) = %v, want %v", tt.where, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package services
+
+import "strings"
+
+// ValidationError lists every problem found in a request before it was sent to AWS
+type ValidationError struct {
+	Problems []string `json:"problems"`
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed: " + strings.Join(e.Problems, "; ")
+}
+
+// validationError returns nil when there are no problems, so callers can return it directly
+func validationError(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+)
+
+// NewPricingClient initializes a Price List client. The API is only served from a few
+// regions and returns prices for all of them, so it always uses us-east-1.
+func NewPricingClient() (*pricing.Client, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+	if err != nil {
+		return nil, err
+	}
+	return pricing.NewFromConfig(cfg), nil
+}